@@ -0,0 +1,116 @@
+package main
+
+import (
+	"overtube/chat_stream"
+	"overtube/control_api"
+	"overtube/save_state"
+	"overtube/ws_server"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// channelStatusTracker guarda o último status de cada canal para a API de controle e para a
+// reconexão automática, a janela tem o seu próprio estado
+type channelStatusTracker struct {
+	mu       sync.Mutex
+	channels map[string]*trackedChannel
+}
+
+type trackedChannel struct {
+	status    control_api.ChannelStatus
+	reconnect bool // Canal salvo ou que já conectou, volta a conectar quando a conexão cai
+}
+
+func newChannelStatusTracker() *channelStatusTracker {
+	return &channelStatusTracker{channels: make(map[string]*trackedChannel)}
+}
+
+// restore lista os canais salvos como parados, só passam a reconectar sozinhos depois de conectar uma vez,
+// assim um canal digitado errado ou apagado não fica sendo tentado para sempre
+func (t *channelStatusTracker) restore(channels []save_state.ChannelConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, channel := range channels {
+		platform := chat_stream.PlatformType(channel.Platform)
+		t.channels[chatStreamKey(platform, channel.Channel)] = &trackedChannel{
+			status: control_api.ChannelStatus{
+				Platform: platform,
+				Channel:  channel.Channel,
+				Label:    channel.Label,
+				Status:   ws_server.ChannelConnectionStopped.String(),
+			},
+		}
+	}
+}
+
+func (t *channelStatusTracker) track(platform chat_stream.PlatformType, channel string, label string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := chatStreamKey(platform, channel)
+	entry, ok := t.channels[key]
+	if !ok {
+		entry = &trackedChannel{}
+		t.channels[key] = entry
+	}
+	entry.status = control_api.ChannelStatus{
+		Platform: platform,
+		Channel:  channel,
+		Label:    label,
+		Status:   ws_server.ChannelConnectionStarting.String(),
+	}
+}
+
+func (t *channelStatusTracker) untrack(platform chat_stream.PlatformType, channel string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.channels, chatStreamKey(platform, channel))
+}
+
+// setStatus ignora canais que não foram adicionados, ex: o "stopped" que chega depois da remoção
+func (t *channelStatusTracker) setStatus(platform chat_stream.PlatformType, channel string, status ws_server.ChannelConnectionStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.channels[chatStreamKey(platform, channel)]
+	if !ok {
+		return
+	}
+	entry.status.Status = status.String()
+	if status == ws_server.ChannelConnectionRunning {
+		entry.reconnect = true
+	}
+}
+
+func (t *channelStatusTracker) setVideoId(platform chat_stream.PlatformType, channel string, videoId string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry, ok := t.channels[chatStreamKey(platform, channel)]; ok {
+		entry.status.VideoId = videoId
+	}
+}
+
+func (t *channelStatusTracker) list() []control_api.ChannelStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]control_api.ChannelStatus, 0, len(t.channels))
+	for _, entry := range t.channels {
+		list = append(list, entry.status)
+	}
+	slices.SortFunc(list, func(a, b control_api.ChannelStatus) int {
+		return strings.Compare(chatStreamKey(a.Platform, a.Channel), chatStreamKey(b.Platform, b.Channel))
+	})
+	return list
+}
+
+// toReconnect lista os canais que já conectaram alguma vez e agora estão parados
+func (t *channelStatusTracker) toReconnect() []control_api.ChannelStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := []control_api.ChannelStatus{}
+	for _, entry := range t.channels {
+		if entry.reconnect && entry.status.Status == ws_server.ChannelConnectionStopped.String() {
+			list = append(list, entry.status)
+		}
+	}
+	return list
+}
@@ -0,0 +1,431 @@
+package chat_stream
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmoteProviderEndpoints permite apontar os provedores de emotes para um servidor local em testes
+type EmoteProviderEndpoints struct {
+	BTTVAPIURL       string
+	BTTVCDNURL       string
+	SevenTVAPIURL    string
+	SevenTVEventsURL string
+}
+
+var DefaultEmoteProviderEndpoints = EmoteProviderEndpoints{
+	BTTVAPIURL:       "https://api.betterttv.net/3",
+	BTTVCDNURL:       "https://cdn.betterttv.net",
+	SevenTVAPIURL:    "https://7tv.io/v3",
+	SevenTVEventsURL: "wss://events.7tv.io/v3",
+}
+
+const EmoteProviderRefreshInterval = 30 * time.Minute
+
+type emoteSource string
+
+const (
+	emoteSourceBTTV    emoteSource = "bttv"
+	emoteSourceFFZ     emoteSource = "ffz"
+	emoteSourceSevenTV emoteSource = "7tv"
+)
+
+// Ordem de prioridade quando dois provedores usam o mesmo código, o último vence
+var emoteSources = []emoteSource{emoteSourceBTTV, emoteSourceFFZ, emoteSourceSevenTV}
+
+var emoteWordCleanRegex = regexp.MustCompile(`[^A-Za-z0-9:]`)
+
+// EmoteProvider carrega os emotes de BTTV, FFZ e 7TV uma única vez e os aplica nas mensagens
+// antes de chegarem aos overlays
+type EmoteProvider struct {
+	endpoints EmoteProviderEndpoints
+	client    http.Client
+	mu        sync.RWMutex
+	sets      map[string]map[string]string // "escopo/fonte" -> código -> URL da imagem
+	channels  map[string]emoteChannel      // "plataforma/usuário"
+	stop      chan struct{}
+
+	sevenTVEvents *sevenTVEventSubscriber
+}
+
+type emoteChannel struct {
+	platform PlatformType
+	userId   string
+}
+
+func NewEmoteProvider() *EmoteProvider {
+	return NewEmoteProviderWithEndpoints(DefaultEmoteProviderEndpoints)
+}
+
+func NewEmoteProviderWithEndpoints(endpoints EmoteProviderEndpoints) *EmoteProvider {
+	provider := &EmoteProvider{
+		endpoints: endpoints,
+		client:    http.Client{Timeout: 10 * time.Second},
+		sets:      map[string]map[string]string{},
+		channels:  map[string]emoteChannel{},
+	}
+	provider.sevenTVEvents = newSevenTVEventSubscriber(endpoints.SevenTVEventsURL, provider.applySevenTVChange)
+	return provider
+}
+
+// Start carrega os emotes globais e passa a atualizar todos os conjuntos periodicamente
+func (p *EmoteProvider) Start() {
+	p.mu.Lock()
+	if p.stop != nil {
+		p.mu.Unlock()
+		return
+	}
+	p.stop = make(chan struct{})
+	stop := p.stop
+	p.mu.Unlock()
+
+	go func() {
+		p.loadGlobalEmotes()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(EmoteProviderRefreshInterval):
+			}
+			p.loadGlobalEmotes()
+			for _, channel := range p.getChannels() {
+				p.loadChannelEmotes(channel.platform, channel.userId)
+			}
+		}
+	}()
+}
+
+func (p *EmoteProvider) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	p.sevenTVEvents.Close()
+}
+
+// AddChannel carrega em segundo plano os emotes do canal, apenas Twitch e YouTube possuem emotes de terceiros
+func (p *EmoteProvider) AddChannel(platform PlatformType, userId string) {
+	if userId == "" || (platform != PlatformTypeTwitch && platform != PlatformTypeYoutube) {
+		return
+	}
+	key := string(platform) + "/" + userId
+	p.mu.Lock()
+	_, exists := p.channels[key]
+	p.channels[key] = emoteChannel{platform: platform, userId: userId}
+	p.mu.Unlock()
+	if !exists {
+		go p.loadChannelEmotes(platform, userId)
+	}
+}
+
+func (p *EmoteProvider) RemoveChannel(platform PlatformType, userId string) {
+	key := string(platform) + "/" + userId
+	p.sevenTVEvents.UnsubscribeChannel(key)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.channels, key)
+	for _, source := range emoteSources {
+		delete(p.sets, key+"/"+string(source))
+	}
+}
+
+// applySevenTVChange aplica no conjunto do canal um emote adicionado, removido ou renomeado no 7TV.
+// Como os emotes são resolvidos no servidor, as próximas mensagens de todos os overlays já usam o novo conjunto
+func (p *EmoteProvider) applySevenTVChange(channelKey string, removedName string, addedName string, addedImgUrl string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	setKey := channelKey + "/" + string(emoteSourceSevenTV)
+	set, ok := p.sets[setKey]
+	if !ok {
+		set = map[string]string{}
+		p.sets[setKey] = set
+	}
+	if removedName != "" {
+		delete(set, removedName)
+		log.Println("[EmoteProvider] 7TV emote removed from", channelKey+":", removedName)
+	}
+	if addedName != "" {
+		set[addedName] = addedImgUrl
+		log.Println("[EmoteProvider] 7TV emote added to", channelKey+":", addedName)
+	}
+}
+
+// ResolveMessageParts quebra as partes de texto em emotes conhecidos para o canal informado
+func (p *EmoteProvider) ResolveMessageParts(platform PlatformType, userId string, parts []ChatStreamMessagePart) []ChatStreamMessagePart {
+	if platform != PlatformTypeTwitch && platform != PlatformTypeYoutube {
+		return parts
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sets := p.getLookupSets(string(platform) + "/" + userId)
+	if len(sets) == 0 {
+		return parts
+	}
+
+	newParts := make([]ChatStreamMessagePart, 0, len(parts))
+	for _, part := range parts {
+		if part.PartType != ChatStreamMessagePartTypeText {
+			newParts = append(newParts, part)
+			continue
+		}
+		textAccumulator := []string{}
+		for _, word := range strings.Split(part.Text, " ") {
+			imgUrl, ok := lookupEmote(sets, emoteWordCleanRegex.ReplaceAllString(word, ""))
+			if !ok {
+				textAccumulator = append(textAccumulator, word)
+				continue
+			}
+			if len(textAccumulator) > 0 {
+				newParts = append(newParts, ChatStreamMessagePart{
+					PartType: ChatStreamMessagePartTypeText,
+					Text:     strings.Join(textAccumulator, " "),
+				})
+				textAccumulator = []string{}
+			}
+			newParts = append(newParts, ChatStreamMessagePart{
+				PartType:    ChatStreamMessagePartTypeEmote,
+				Text:        word,
+				EmoteName:   word,
+				EmoteImgUrl: imgUrl,
+			})
+		}
+		if len(textAccumulator) > 0 {
+			newParts = append(newParts, ChatStreamMessagePart{
+				PartType: ChatStreamMessagePartTypeText,
+				Text:     strings.Join(textAccumulator, " "),
+			})
+		}
+	}
+	return newParts
+}
+
+// getLookupSets retorna os conjuntos do mais prioritário (7TV do canal) ao menos prioritário (BTTV global)
+func (p *EmoteProvider) getLookupSets(channelKey string) []map[string]string {
+	sets := []map[string]string{}
+	for _, scope := range []string{channelKey, "global"} {
+		for i := len(emoteSources) - 1; i >= 0; i-- {
+			if set, ok := p.sets[scope+"/"+string(emoteSources[i])]; ok && len(set) > 0 {
+				sets = append(sets, set)
+			}
+		}
+	}
+	return sets
+}
+
+func lookupEmote(sets []map[string]string, word string) (string, bool) {
+	if word == "" {
+		return "", false
+	}
+	for _, set := range sets {
+		if imgUrl, ok := set[word]; ok {
+			return imgUrl, true
+		}
+	}
+	return "", false
+}
+
+func (p *EmoteProvider) getChannels() []emoteChannel {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	channels := make([]emoteChannel, 0, len(p.channels))
+	for _, channel := range p.channels {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// setEmotes só substitui o conjunto quando a busca deu certo, mantendo o anterior se a API estiver fora
+func (p *EmoteProvider) setEmotes(key string, emotes map[string]string, err error) {
+	if err != nil {
+		log.Println("[EmoteProvider] Fail to load emotes for", key, err)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sets[key] = emotes
+}
+
+func (p *EmoteProvider) loadGlobalEmotes() {
+	emotes, err := p.fetchBTTVEmoteList(p.endpoints.BTTVAPIURL + "/cached/emotes/global")
+	p.setEmotes("global/"+string(emoteSourceBTTV), emotes, err)
+
+	emotes, err = p.fetchFFZEmoteList(p.endpoints.BTTVAPIURL + "/cached/frankerfacez/emotes/global")
+	p.setEmotes("global/"+string(emoteSourceFFZ), emotes, err)
+
+	emotes, _, err = p.fetchSevenTVEmoteSet(p.endpoints.SevenTVAPIURL+"/emote-sets/global", []any{})
+	p.setEmotes("global/"+string(emoteSourceSevenTV), emotes, err)
+}
+
+func (p *EmoteProvider) loadChannelEmotes(platform PlatformType, userId string) {
+	key := string(platform) + "/" + userId
+
+	emotes, err := p.fetchBTTVUserEmotes(p.endpoints.BTTVAPIURL + "/cached/users/" + string(platform) + "/" + userId)
+	p.setEmotes(key+"/"+string(emoteSourceBTTV), emotes, err)
+
+	emotes, err = p.fetchFFZEmoteList(p.endpoints.BTTVAPIURL + "/cached/frankerfacez/users/" + string(platform) + "/" + userId)
+	p.setEmotes(key+"/"+string(emoteSourceFFZ), emotes, err)
+
+	if platform == PlatformTypeTwitch {
+		emotes, setId, err := p.fetchSevenTVEmoteSet(p.endpoints.SevenTVAPIURL+"/users/twitch/"+userId, []any{"emote_set"})
+		p.setEmotes(key+"/"+string(emoteSourceSevenTV), emotes, err)
+		if err == nil && setId != "" {
+			p.sevenTVEvents.Subscribe(setId, key)
+		}
+	}
+}
+
+func (p *EmoteProvider) fetchBTTVUserEmotes(url string) (map[string]string, error) {
+	data, err := p.fetchEmoteJson(url)
+	if err != nil {
+		return nil, err
+	}
+	userData, ok := data.(map[string]any)
+	if !ok {
+		return nil, &CustomError{message: "BTTV user data is not in expected format"}
+	}
+	emotes := map[string]string{}
+	for _, listKey := range []string{"channelEmotes", "sharedEmotes"} {
+		list, _ := userData[listKey].([]any)
+		p.addBTTVEmotes(emotes, list)
+	}
+	return emotes, nil
+}
+
+func (p *EmoteProvider) fetchBTTVEmoteList(url string) (map[string]string, error) {
+	data, err := p.fetchEmoteJson(url)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := data.([]any)
+	if !ok {
+		return nil, &CustomError{message: "BTTV emote list is not in expected format"}
+	}
+	emotes := map[string]string{}
+	p.addBTTVEmotes(emotes, list)
+	return emotes, nil
+}
+
+func (p *EmoteProvider) addBTTVEmotes(emotes map[string]string, list []any) {
+	for _, item := range list {
+		emote, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		id, _ := emote["id"].(string)
+		code, _ := emote["code"].(string)
+		imageType, _ := emote["imageType"].(string)
+		if id == "" || code == "" {
+			continue
+		}
+		emotes[code] = p.endpoints.BTTVCDNURL + "/emote/" + id + "/2x." + imageType
+	}
+}
+
+func (p *EmoteProvider) fetchFFZEmoteList(url string) (map[string]string, error) {
+	data, err := p.fetchEmoteJson(url)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := data.([]any)
+	if !ok {
+		return nil, &CustomError{message: "FFZ emote list is not in expected format"}
+	}
+	emotes := map[string]string{}
+	for _, item := range list {
+		emote, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		code, _ := emote["code"].(string)
+		images, _ := emote["images"].(map[string]any)
+		if code == "" || images == nil {
+			continue
+		}
+		// Nem todo emote do FFZ possui a versão 4x
+		for _, size := range []string{"4x", "2x", "1x"} {
+			if imgUrl, ok := images[size].(string); ok && imgUrl != "" {
+				emotes[code] = imgUrl
+				break
+			}
+		}
+	}
+	return emotes, nil
+}
+
+// fetchSevenTVEmoteSet busca um conjunto do 7TV, setPath indica onde o conjunto está na resposta
+func (p *EmoteProvider) fetchSevenTVEmoteSet(url string, setPath []any) (map[string]string, string, error) {
+	data, err := p.fetchEmoteJson(url)
+	if err != nil {
+		return nil, "", err
+	}
+	dataMap, ok := data.(map[string]any)
+	if !ok {
+		return nil, "", &CustomError{message: "7TV data is not in expected format"}
+	}
+	emotes := map[string]string{}
+	setData, ok := GetDeepMapValue(dataMap, setPath, true)
+	emoteSet, isMap := setData.(map[string]any)
+	if !ok || !isMap {
+		// Canal sem conjunto de emotes ativo no 7TV
+		return emotes, "", nil
+	}
+	setId, _ := emoteSet["id"].(string)
+	list, _ := emoteSet["emotes"].([]any)
+	for _, item := range list {
+		emote, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if name, imgUrl, ok := getSevenTVEmote(emote); ok {
+			emotes[name] = imgUrl
+		}
+	}
+	return emotes, setId, nil
+}
+
+func getSevenTVEmote(emote map[string]any) (string, string, bool) {
+	name, _ := emote["name"].(string)
+	rawHostUrl, _ := GetDeepMapValue(emote, []any{"data", "host", "url"}, true)
+	rawFileName, _ := GetDeepMapValue(emote, []any{"data", "host", "files", 1, "name"}, true)
+	hostUrl, okUrl := rawHostUrl.(string)
+	fileName, okFile := rawFileName.(string)
+	if name == "" || !okUrl || !okFile || hostUrl == "" || fileName == "" {
+		return "", "", false
+	}
+	imgUrl := hostUrl + "/" + fileName
+	if strings.HasPrefix(imgUrl, "//") {
+		imgUrl = "https:" + imgUrl // O 7TV devolve URLs sem protocolo
+	}
+	return name, imgUrl, true
+}
+
+func (p *EmoteProvider) fetchEmoteJson(url string) (any, error) {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &CustomError{message: "Failed to fetch " + url + ", status code: " + strconv.Itoa(resp.StatusCode)}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var data any
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
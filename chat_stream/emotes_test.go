@@ -0,0 +1,150 @@
+package chat_stream
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeSevenTVEmote monta um emote no formato da API v3 do 7TV
+func fakeSevenTVEmote(name string, id string) map[string]any {
+	return map[string]any{
+		"name": name,
+		"data": map[string]any{"host": map[string]any{
+			"url":   "//cdn.7tv.app/emote/" + id,
+			"files": []any{map[string]any{"name": "1x.webp"}, map[string]any{"name": "2x.webp"}},
+		}},
+	}
+}
+
+// fakeEmoteServer responde as rotas do BTTV, FFZ e 7TV usadas pelo EmoteProvider, routes pode sobrescrever qualquer uma
+func fakeEmoteServer(routes map[string]any) *httptest.Server {
+	responses := map[string]any{
+		"/bttv/cached/emotes/global": []any{
+			map[string]any{"id": "b1", "code": "Shared", "imageType": "png"},
+			map[string]any{"id": "b2", "code": "BttvGlobal", "imageType": "gif"},
+			map[string]any{"code": "SemId"},
+		},
+		"/bttv/cached/frankerfacez/emotes/global": []any{
+			map[string]any{"code": "FfzGlobal", "images": map[string]any{"1x": "https://cdn.ffz/1", "2x": "https://cdn.ffz/2"}},
+		},
+		"/7tv/emote-sets/global": map[string]any{
+			"id":     "global",
+			"emotes": []any{fakeSevenTVEmote("SevenGlobal", "g1")},
+		},
+		"/bttv/cached/users/twitch/123": map[string]any{
+			"channelEmotes": []any{map[string]any{"id": "c1", "code": "BttvChannel", "imageType": "png"}},
+			"sharedEmotes":  []any{map[string]any{"id": "c2", "code": "BttvShared", "imageType": "png"}},
+		},
+		"/bttv/cached/frankerfacez/users/twitch/123": []any{},
+		"/7tv/users/twitch/123": map[string]any{
+			"emote_set": map[string]any{
+				"id":     "set123",
+				"emotes": []any{fakeSevenTVEmote("Shared", "s1"), map[string]any{"name": "SemHost"}},
+			},
+		},
+	}
+	for path, response := range routes {
+		responses[path] = response
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if status, ok := response.(int); ok {
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func newTestEmoteProvider(server *httptest.Server, sevenTVEventsURL string) *EmoteProvider {
+	return NewEmoteProviderWithEndpoints(EmoteProviderEndpoints{
+		BTTVAPIURL:       server.URL + "/bttv",
+		BTTVCDNURL:       "https://cdn.bttv",
+		SevenTVAPIURL:    server.URL + "/7tv",
+		SevenTVEventsURL: sevenTVEventsURL,
+	})
+}
+
+// resolveEmotes devolve o código -> URL dos emotes encontrados no texto
+func resolveEmotes(provider *EmoteProvider, userId string, text string) map[string]string {
+	parts := provider.ResolveMessageParts(PlatformTypeTwitch, userId, []ChatStreamMessagePart{
+		{PartType: ChatStreamMessagePartTypeText, Text: text},
+	})
+	emotes := map[string]string{}
+	for _, part := range parts {
+		if part.PartType == ChatStreamMessagePartTypeEmote {
+			emotes[part.EmoteName] = part.EmoteImgUrl
+		}
+	}
+	return emotes
+}
+
+func TestEmoteProviderLoadsGlobalAndChannelEmotes(t *testing.T) {
+	server := fakeEmoteServer(nil)
+	defer server.Close()
+	provider := newTestEmoteProvider(server, "")
+	defer provider.Stop()
+
+	provider.loadGlobalEmotes()
+	provider.loadChannelEmotes(PlatformTypeTwitch, "123")
+
+	got := resolveEmotes(provider, "123", "Shared BttvGlobal FfzGlobal SevenGlobal BttvChannel BttvShared SemId SemHost")
+	expected := map[string]string{
+		"Shared":      "https://cdn.7tv.app/emote/s1/2x.webp", // O 7TV do canal vence o BTTV global
+		"BttvGlobal":  "https://cdn.bttv/emote/b2/2x.gif",
+		"FfzGlobal":   "https://cdn.ffz/2",
+		"SevenGlobal": "https://cdn.7tv.app/emote/g1/2x.webp",
+		"BttvChannel": "https://cdn.bttv/emote/c1/2x.png",
+		"BttvShared":  "https://cdn.bttv/emote/c2/2x.png",
+	}
+	if len(got) != len(expected) {
+		t.Errorf("resolved %v, want %v", got, expected)
+	}
+	for code, imgUrl := range expected {
+		if got[code] != imgUrl {
+			t.Errorf("%s = %q, want %q", code, got[code], imgUrl)
+		}
+	}
+
+	// Outro canal só enxerga os globais
+	if other := resolveEmotes(provider, "456", "Shared BttvChannel"); other["Shared"] != "https://cdn.bttv/emote/b1/2x.png" || other["BttvChannel"] != "" {
+		t.Errorf("other channel resolved %v", other)
+	}
+}
+
+func TestEmoteProviderKeepsEmotesWhenAPIFails(t *testing.T) {
+	server := fakeEmoteServer(nil)
+	provider := newTestEmoteProvider(server, "")
+	defer provider.Stop()
+	provider.loadGlobalEmotes()
+	server.Close()
+
+	failing := fakeEmoteServer(map[string]any{"/bttv/cached/emotes/global": http.StatusInternalServerError})
+	defer failing.Close()
+	provider.endpoints.BTTVAPIURL = failing.URL + "/bttv"
+	provider.loadGlobalEmotes()
+
+	if got := resolveEmotes(provider, "", "BttvGlobal"); got["BttvGlobal"] != "https://cdn.bttv/emote/b2/2x.gif" {
+		t.Errorf("BTTV global emotes were dropped after a failed refresh: %v", got)
+	}
+}
+
+func TestEmoteProviderRemoveChannelDropsChannelSets(t *testing.T) {
+	server := fakeEmoteServer(nil)
+	defer server.Close()
+	provider := newTestEmoteProvider(server, "")
+	defer provider.Stop()
+
+	provider.loadChannelEmotes(PlatformTypeTwitch, "123")
+	provider.RemoveChannel(PlatformTypeTwitch, "123")
+
+	if got := resolveEmotes(provider, "123", "BttvChannel Shared"); len(got) != 0 {
+		t.Errorf("removed channel still resolves %v", got)
+	}
+}
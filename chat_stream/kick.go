@@ -0,0 +1,432 @@
+package chat_stream
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// KickEndpoints permite apontar o conector para outro servidor, como um fake local em testes
+type KickEndpoints struct {
+	APIBaseURL string
+	PusherURL  string
+	EmoteURL   string
+}
+
+var DefaultKickEndpoints = KickEndpoints{
+	APIBaseURL: "https://kick.com",
+	PusherURL:  "wss://ws-us2.pusher.com/app/32cbd69e4b950bf97679?protocol=7&client=js&version=8.4.0-rc2&flash=false",
+	EmoteURL:   "https://files.kick.com/emotes/",
+}
+
+var kickEmoteRegex = regexp.MustCompile(`\[emote:(\d+):([^\]]*)\]`)
+
+func ConnectToKickChat(channelID string) (ChatStreamCon, error) {
+	return ConnectToKickChatWithEndpoints(channelID, DefaultKickEndpoints)
+}
+
+func ConnectToKickChatWithEndpoints(channelID string, endpoints KickEndpoints) (ChatStreamCon, error) {
+	log.Println("Starting Kick chat stream for channel:", channelID)
+	con := &KickChatStreamCon{
+		ChannelID: channelID,
+		endpoints: endpoints,
+		stream:    make(chan ChatStreamMessage, ChatStreamMessageBufferSize),
+		events:    make(chan ChatStreamEvent, ChatStreamMessageBufferSize),
+		deletions: make(chan ChatStreamDeletion, ChatStreamMessageBufferSize),
+		done:      make(chan struct{}),
+	}
+
+	err := fillKickChannelData(con)
+	if err != nil {
+		log.Println("Error getting Kick channel data:", err)
+		return nil, err
+	}
+
+	c, _, err := websocket.DefaultDialer.Dial(endpoints.PusherURL, nil)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	con.ws = c
+
+	err = initKickChatStream(con)
+	if err != nil {
+		log.Println("Error initializing Kick chat stream:", err)
+		con.Close()
+		return nil, err
+	}
+
+	go iterateOnKickMessages(con)
+
+	return con, nil
+}
+
+func fillKickChannelData(con *KickChatStreamCon) error {
+	req, err := http.NewRequest(http.MethodGet, con.endpoints.APIBaseURL+"/api/v2/channels/"+con.ChannelID, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36")
+
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &CustomError{message: "Failed to fetch Kick channel, status code: " + strconv.Itoa(resp.StatusCode)}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var data map[string]any
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return err
+	}
+
+	chatroomId, ok := GetDeepMapValue(data, []any{"chatroom", "id"}, false)
+	if !ok {
+		return &CustomError{message: "Chatroom ID not found in Kick channel data"}
+	}
+	con.ChatroomID = kickIdToString(chatroomId)
+	if con.ChatroomID == "" {
+		return &CustomError{message: "Invalid chatroom ID in Kick channel data"}
+	}
+
+	userId, ok := GetDeepMapValue(data, []any{"user_id"}, false)
+	if ok {
+		con.UserID = kickIdToString(userId)
+	}
+
+	fillKickBadgesDatabase(con, data)
+
+	return nil
+}
+
+// fillKickBadgesDatabase monta os selos de cargo sem imagem, a Kick não publica essas imagens em um CDN
+// e o overlay mostra o selo como texto com a classe do tipo. Os selos de inscrito vêm do próprio canal
+func fillKickBadgesDatabase(con *KickChatStreamCon, channelData map[string]any) {
+	var badges map[string]ChatUserBadge = map[string]ChatUserBadge{}
+
+	badges["broadcaster"] = ChatUserBadge{
+		Name: "Broadcaster",
+		Type: "broadcaster",
+	}
+	badges["moderator"] = ChatUserBadge{
+		Name: "Moderator",
+		Type: "moderator",
+	}
+	badges["vip"] = ChatUserBadge{
+		Name: "VIP",
+		Type: "vip",
+	}
+	badges["verified"] = ChatUserBadge{
+		Name: "Verified",
+		Type: "verified",
+	}
+	badges["founder"] = ChatUserBadge{
+		Name: "Founder",
+		Type: "founder",
+	}
+	badges["staff"] = ChatUserBadge{
+		Name: "Kick Staff",
+		Type: "staff",
+	}
+
+	con.badgesDB = badges
+	con.subscriberBadges = []KickSubscriberBadge{}
+
+	subBadges, ok := GetDeepMapValue(channelData, []any{"subscriber_badges"}, true)
+	if !ok {
+		return
+	}
+	subBadgeList, ok := subBadges.([]any)
+	if !ok {
+		return
+	}
+	for _, badge := range subBadgeList {
+		badgeData, ok := badge.(map[string]any)
+		if !ok {
+			continue
+		}
+		months, ok := badgeData["months"].(float64)
+		if !ok {
+			continue
+		}
+		imgSrc, ok := GetDeepMapValue(badgeData, []any{"badge_image", "src"}, false)
+		if !ok {
+			continue
+		}
+		imgSrcText, ok := imgSrc.(string)
+		if !ok {
+			continue
+		}
+		con.subscriberBadges = append(con.subscriberBadges, KickSubscriberBadge{
+			Months: int(months),
+			ImgSrc: imgSrcText,
+		})
+	}
+	sort.Slice(con.subscriberBadges, func(i, j int) bool {
+		return con.subscriberBadges[i].Months < con.subscriberBadges[j].Months
+	})
+}
+
+func initKickChatStream(con *KickChatStreamCon) error {
+	// O primeiro evento do Pusher confirma que a conexão foi estabelecida
+	con.ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+	_, message, err := con.ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	con.ws.SetReadDeadline(time.Time{})
+	var established map[string]any
+	err = json.Unmarshal(message, &established)
+	if err != nil {
+		return err
+	}
+	if established["event"] != "pusher:connection_established" {
+		return &CustomError{message: "Unexpected first event from Kick chat: " + string(message)}
+	}
+
+	return con.ws.WriteJSON(map[string]any{
+		"event": "pusher:subscribe",
+		"data": map[string]any{
+			"auth":    "",
+			"channel": "chatrooms." + con.ChatroomID + ".v2",
+		},
+	})
+}
+
+// iterateOnKickMessages é a única que envia nos canais da conexão, então só ela os fecha
+func iterateOnKickMessages(con *KickChatStreamCon) {
+	defer close(con.stream)
+	defer close(con.events)
+	defer close(con.deletions)
+	pingTimerval := time.NewTicker(30 * time.Second)
+	defer pingTimerval.Stop()
+	for {
+		if !con.IsConnected() {
+			return
+		}
+		select {
+		case <-pingTimerval.C:
+			con.ws.WriteJSON(map[string]any{"event": "pusher:ping", "data": map[string]any{}})
+		default:
+			// Continue to read messages
+		}
+
+		_, message, err := con.ws.ReadMessage()
+		if err != nil {
+			if con.IsConnected() {
+				log.Println(err)
+			}
+			con.Close()
+			return
+		}
+		handleKickPayload(con, message)
+	}
+}
+
+func handleKickPayload(con *KickChatStreamCon, payload []byte) {
+	var envelope map[string]any
+	err := json.Unmarshal(payload, &envelope)
+	if err != nil {
+		log.Println("Error unmarshaling Kick payload:", err)
+		return
+	}
+	eventName, _ := envelope["event"].(string)
+	if eventName == "pusher:ping" {
+		con.ws.WriteJSON(map[string]any{"event": "pusher:pong", "data": map[string]any{}})
+		return
+	}
+
+	// Os eventos do Pusher trazem o campo data como uma string JSON
+	rawData, ok := envelope["data"].(string)
+	if !ok {
+		return
+	}
+	var data map[string]any
+	err = json.Unmarshal([]byte(rawData), &data)
+	if err != nil {
+		return
+	}
+
+	switch eventName {
+	case "App\\Events\\ChatMessageEvent":
+		parsed, err := parseKickMessage(con, data)
+		if err != nil || parsed == nil {
+			return
+		}
+		select {
+		case con.stream <- *parsed:
+		case <-con.done:
+		}
+	case "App\\Events\\MessageDeletedEvent":
+		rawMessageId, _ := GetDeepMapValue(data, []any{"message", "id"}, false)
+		messageId, ok := rawMessageId.(string)
+		if !ok || messageId == "" {
+			return
+		}
+		sendKickDeletion(con, ChatStreamDeletion{
+			Platform:     PlatformTypeKick,
+			DeletionType: ChatStreamDeletionTypeMessage,
+			MessageId:    messageId,
+		})
+	case "App\\Events\\UserBannedEvent":
+		userId, ok := GetDeepMapValue(data, []any{"user", "id"}, false)
+		if !ok {
+			return
+		}
+		sendKickDeletion(con, ChatStreamDeletion{
+			Platform:     PlatformTypeKick,
+			DeletionType: ChatStreamDeletionTypeUser,
+			UserId:       kickIdToString(userId),
+		})
+	case "App\\Events\\ChatroomClearEvent":
+		sendKickDeletion(con, ChatStreamDeletion{
+			Platform:     PlatformTypeKick,
+			DeletionType: ChatStreamDeletionTypeAll,
+		})
+	}
+}
+
+func sendKickDeletion(con *KickChatStreamCon, deletion ChatStreamDeletion) {
+	select {
+	case con.deletions <- deletion:
+	case <-con.done:
+	}
+}
+
+func parseKickMessage(con *KickChatStreamCon, data map[string]any) (*ChatStreamMessage, error) {
+	content, ok := data["content"].(string)
+	if !ok {
+		return nil, &CustomError{message: "Content not found in Kick message"}
+	}
+	rawName, _ := GetDeepMapValue(data, []any{"sender", "username"}, false)
+	name, ok := rawName.(string)
+	if !ok {
+		return nil, &CustomError{message: "Sender not found in Kick message"}
+	}
+
+	timestamp := time.Now().Unix()
+	if createdAt, ok := data["created_at"].(string); ok {
+		parsedTime, err := time.Parse(time.RFC3339, createdAt)
+		if err == nil {
+			timestamp = parsedTime.Unix()
+		}
+	}
+
+	messageId, _ := data["id"].(string)
+	userId, _ := GetDeepMapValue(data, []any{"sender", "id"}, true)
+
+	return &ChatStreamMessage{
+		Platform:     PlatformTypeKick,
+		Id:           messageId,
+		UserId:       kickIdToString(userId),
+		Name:         name,
+		MessageParts: parseKickMessageParts(con, content),
+		Timestamp:    timestamp,
+		Badges:       parseKickBadges(con, data),
+	}, nil
+}
+
+func parseKickMessageParts(con *KickChatStreamCon, content string) []ChatStreamMessagePart {
+	parts := []ChatStreamMessagePart{}
+	cursor := 0
+	for _, match := range kickEmoteRegex.FindAllStringSubmatchIndex(content, -1) {
+		if match[0] > cursor {
+			parts = append(parts, ChatStreamMessagePart{
+				PartType: ChatStreamMessagePartTypeText,
+				Text:     content[cursor:match[0]],
+			})
+		}
+		emoteId := content[match[2]:match[3]]
+		emoteName := content[match[4]:match[5]]
+		parts = append(parts, ChatStreamMessagePart{
+			PartType:    ChatStreamMessagePartTypeEmote,
+			Text:        emoteName,
+			EmoteImgUrl: con.endpoints.EmoteURL + emoteId + "/fullsize",
+			EmoteName:   emoteName,
+		})
+		cursor = match[1]
+	}
+	if cursor < len(content) {
+		parts = append(parts, ChatStreamMessagePart{
+			PartType: ChatStreamMessagePartTypeText,
+			Text:     content[cursor:],
+		})
+	}
+	return parts
+}
+
+func parseKickBadges(con *KickChatStreamCon, data map[string]any) []ChatUserBadge {
+	badges := []ChatUserBadge{}
+	rawBadges, ok := GetDeepMapValue(data, []any{"sender", "identity", "badges"}, true)
+	if !ok {
+		return badges
+	}
+	badgeList, ok := rawBadges.([]any)
+	if !ok {
+		return badges
+	}
+	for _, badge := range badgeList {
+		badgeData, ok := badge.(map[string]any)
+		if !ok {
+			continue
+		}
+		badgeType, _ := badgeData["type"].(string)
+		if badgeType == "subscriber" {
+			months, _ := badgeData["count"].(float64)
+			subBadge, ok := getKickSubscriberBadge(con, int(months))
+			if ok {
+				badges = append(badges, subBadge)
+			}
+			continue
+		}
+		badgeEntry, ok := con.badgesDB[badgeType]
+		if !ok {
+			continue
+		}
+		badges = append(badges, badgeEntry)
+	}
+	return badges
+}
+
+func getKickSubscriberBadge(con *KickChatStreamCon, months int) (ChatUserBadge, bool) {
+	found := false
+	badge := ChatUserBadge{Name: "Subscriber", Type: "subscriber"}
+	for _, subBadge := range con.subscriberBadges {
+		if subBadge.Months > months && found {
+			break
+		}
+		badge.ImgSrc = subBadge.ImgSrc
+		found = true
+	}
+	return badge, found
+}
+
+// kickIdToString normaliza ids que a Kick envia ora como número, ora como texto
+func kickIdToString(id any) string {
+	switch v := id.(type) {
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,135 @@
+package chat_stream
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeKickServer responde a API do canal e faz o papel do Pusher, enviando os eventos na ordem dada
+func fakeKickServer(t *testing.T, events []map[string]any) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/channels/canal", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"user_id":  42,
+			"chatroom": map[string]any{"id": 1234},
+			"subscriber_badges": []any{
+				map[string]any{"months": 6, "badge_image": map[string]any{"src": "https://files.kick.com/sub6.png"}},
+				map[string]any{"months": 1, "badge_image": map[string]any{"src": "https://files.kick.com/sub1.png"}},
+				map[string]any{"months": 12, "badge_image": map[string]any{"src": 12}},
+			},
+		})
+	})
+	mux.HandleFunc("/pusher", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(map[string]any{"event": "pusher:connection_established", "data": "{}"})
+		var subscribe map[string]any
+		if err := conn.ReadJSON(&subscribe); err != nil {
+			t.Error(err)
+			return
+		}
+		channel, _ := GetDeepMapValue(subscribe, []any{"data", "channel"}, false)
+		if channel != "chatrooms.1234.v2" {
+			t.Errorf("subscribed to %v", channel)
+		}
+		for _, event := range events {
+			data, _ := json.Marshal(event["data"])
+			conn.WriteJSON(map[string]any{"event": event["event"], "data": string(data)})
+		}
+		// Mantém a conexão aberta até o cliente encerrar
+		conn.ReadMessage()
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestKickChatStreamParsesPusherEvents(t *testing.T) {
+	server := fakeKickServer(t, []map[string]any{
+		{"event": "App\\Events\\ChatMessageEvent", "data": map[string]any{
+			"id":         "msg-1",
+			"content":    "oi [emote:37226:KEKW] tudo bem",
+			"created_at": "2025-01-02T03:04:05Z",
+			"sender": map[string]any{
+				"id":       99,
+				"username": "viewer",
+				"identity": map[string]any{"badges": []any{
+					map[string]any{"type": "moderator"},
+					map[string]any{"type": "subscriber", "count": 7},
+				}},
+			},
+		}},
+		{"event": "App\\Events\\ChatMessageEvent", "data": map[string]any{"id": "msg-2", "content": "sem remetente"}},
+		{"event": "App\\Events\\MessageDeletedEvent", "data": map[string]any{"message": map[string]any{"id": 123}}},
+		{"event": "App\\Events\\MessageDeletedEvent", "data": map[string]any{"message": map[string]any{"id": "msg-1"}}},
+		{"event": "App\\Events\\UserBannedEvent", "data": map[string]any{"user": map[string]any{"id": 99}}},
+	})
+	defer server.Close()
+
+	con, err := ConnectToKickChatWithEndpoints("canal", KickEndpoints{
+		APIBaseURL: server.URL,
+		PusherURL:  "ws" + strings.TrimPrefix(server.URL, "http") + "/pusher",
+		EmoteURL:   "https://files.kick.com/emotes/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Close()
+	if con.GetUserId() != "42" {
+		t.Errorf("user id = %q", con.GetUserId())
+	}
+
+	var message ChatStreamMessage
+	select {
+	case message = <-con.GetMessagesChan():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+	if message.Id != "msg-1" || message.UserId != "99" || message.Name != "viewer" {
+		t.Errorf("unexpected message %+v", message)
+	}
+	if message.Timestamp != time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).Unix() {
+		t.Errorf("timestamp = %d", message.Timestamp)
+	}
+	if len(message.MessageParts) != 3 || message.MessageParts[1].EmoteImgUrl != "https://files.kick.com/emotes/37226/fullsize" {
+		t.Errorf("unexpected parts %+v", message.MessageParts)
+	}
+	if len(message.Badges) != 2 {
+		t.Fatalf("unexpected badges %+v", message.Badges)
+	}
+	if message.Badges[0].Type != "moderator" || message.Badges[0].ImgSrc != "" {
+		t.Errorf("moderator badge = %+v", message.Badges[0])
+	}
+	if message.Badges[1].ImgSrc != "https://files.kick.com/sub6.png" {
+		t.Errorf("subscriber badge = %+v", message.Badges[1])
+	}
+
+	expected := []ChatStreamDeletion{
+		{Platform: PlatformTypeKick, DeletionType: ChatStreamDeletionTypeMessage, MessageId: "msg-1"},
+		{Platform: PlatformTypeKick, DeletionType: ChatStreamDeletionTypeUser, UserId: "99"},
+	}
+	for _, want := range expected {
+		select {
+		case got := <-con.GetDeletionsChan():
+			if got != want {
+				t.Errorf("deletion = %+v, want %+v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for deletion")
+		}
+	}
+	select {
+	case extra := <-con.GetMessagesChan():
+		t.Errorf("message without sender should be dropped, got %+v", extra)
+	default:
+	}
+}
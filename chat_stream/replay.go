@@ -0,0 +1,215 @@
+package chat_stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+)
+
+// ReplayChatFromFile reproduz um arquivo gravado pelo servidor WS respeitando o intervalo
+// original entre as mensagens, dividido por speed (2 reproduz com o dobro da velocidade).
+// Cada canal gravado vira uma conexão, assim o filtro ?channel= e os rótulos funcionam como ao vivo
+func ReplayChatFromFile(filePath string, speed float64) ([]*ReplayChatStreamCon, error) {
+	if speed <= 0 {
+		speed = 1
+	}
+	cons, err := scanReplayChannels(filePath, speed)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Starting chat replay from file:", filePath, "at speed", speed, "with", len(cons), "channels")
+	go iterateOnReplayRecords(cons, file)
+	return cons, nil
+}
+
+// scanReplayChannels lê o arquivo uma vez só para descobrir os canais gravados, na ordem em que aparecem
+func scanReplayChannels(filePath string, speed float64) ([]*ReplayChatStreamCon, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cons := []*ReplayChatStreamCon{}
+	seen := map[string]bool{}
+	scanner := newReplayScanner(file)
+	for scanner.Scan() {
+		var record struct {
+			Channel      string
+			ChannelLabel string
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		channel := replayRecordChannel(record.Channel, filePath)
+		if seen[channel] {
+			continue
+		}
+		seen[channel] = true
+		cons = append(cons, &ReplayChatStreamCon{
+			FilePath:  filePath,
+			Speed:     speed,
+			Channel:   channel,
+			Label:     record.ChannelLabel,
+			stream:    make(chan ChatStreamMessage, ChatStreamMessageBufferSize),
+			events:    make(chan ChatStreamEvent, ChatStreamMessageBufferSize),
+			deletions: make(chan ChatStreamDeletion, ChatStreamMessageBufferSize),
+			done:      make(chan struct{}),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cons) == 0 {
+		return nil, &CustomError{message: "Chat replay file has no records: " + filePath}
+	}
+	return cons, nil
+}
+
+func newReplayScanner(file *os.File) *bufio.Scanner {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024) // Mensagens com muitos emotes podem ser longas
+	return scanner
+}
+
+// Gravações sem o canal ficam todas sob o nome do arquivo
+func replayRecordChannel(channel string, filePath string) string {
+	if channel == "" {
+		return filePath
+	}
+	return channel
+}
+
+// iterateOnReplayRecords é a única que fecha os canais das conexões, Close só pede para parar
+func iterateOnReplayRecords(cons []*ReplayChatStreamCon, file *os.File) {
+	defer file.Close()
+	defer func() {
+		for _, con := range cons {
+			close(con.stream)
+			close(con.events)
+			close(con.deletions)
+		}
+	}()
+
+	byChannel := make(map[string]*ReplayChatStreamCon, len(cons))
+	for _, con := range cons {
+		byChannel[con.Channel] = con
+	}
+	filePath := cons[0].FilePath
+	speed := cons[0].Speed
+	finished := make(chan struct{})
+	defer close(finished)
+	stopped := replayStopped(cons, finished)
+
+	scanner := newReplayScanner(file)
+	var lastRecordedAt int64 = 0
+	for scanner.Scan() {
+		if !anyReplayConnected(cons) {
+			log.Println("Chat replay closed, stopping replay")
+			return
+		}
+		var record ChatRecord
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			log.Println("Error parsing chat replay record:", err)
+			continue
+		}
+
+		if lastRecordedAt > 0 && record.RecordedAt > lastRecordedAt {
+			delay := time.Duration(float64(record.RecordedAt-lastRecordedAt)/speed) * time.Millisecond
+			select {
+			case <-time.After(delay):
+			case <-stopped:
+				log.Println("Chat replay closed, stopping replay")
+				return
+			}
+		}
+		lastRecordedAt = record.RecordedAt
+
+		con, ok := byChannel[replayRecordChannel(record.Channel, filePath)]
+		if !ok {
+			continue
+		}
+		streamReplayRecord(con, &record)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Println("Error reading chat replay file:", err)
+		return
+	}
+	log.Println("Chat replay finished:", filePath)
+
+	// Aguarda o servidor consumir o que ainda está nos buffers antes de encerrar as conexões
+	for _, con := range cons {
+		for con.IsConnected() && len(con.stream)+len(con.events)+len(con.deletions) > 0 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+// replayStopped fecha o canal retornado quando todas as conexões forem fechadas, assim uma pausa
+// longa entre mensagens não segura a reprodução depois do Close
+func replayStopped(cons []*ReplayChatStreamCon, finished <-chan struct{}) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		for _, con := range cons {
+			select {
+			case <-con.done:
+			case <-finished:
+				return
+			}
+		}
+		close(stopped)
+	}()
+	return stopped
+}
+
+func anyReplayConnected(cons []*ReplayChatStreamCon) bool {
+	for _, con := range cons {
+		if con.IsConnected() {
+			return true
+		}
+	}
+	return false
+}
+
+func streamReplayRecord(con *ReplayChatStreamCon, record *ChatRecord) {
+	if !con.IsConnected() {
+		return
+	}
+	switch record.Type {
+	case ChatRecordTypeMessage:
+		if record.Message == nil {
+			return
+		}
+		select {
+		case con.stream <- *record.Message:
+		default:
+			log.Println("Chat stream buffer is full, dropping message:", record.Message)
+		}
+	case ChatRecordTypeEvent:
+		if record.Event == nil {
+			return
+		}
+		select {
+		case con.events <- *record.Event:
+		default:
+			log.Println("Chat stream buffer is full, dropping event:", record.Event)
+		}
+	case ChatRecordTypeDeletion:
+		if record.Deletion == nil {
+			return
+		}
+		select {
+		case con.deletions <- *record.Deletion:
+		default:
+			log.Println("Chat stream buffer is full, dropping deletion:", record.Deletion)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package chat_stream
+
+import "strings"
+
+// ChatUserRole é o cargo mais alto de quem mandou a mensagem, ordenado do menor para o maior
+type ChatUserRole string
+
+const (
+	ChatUserRoleViewer      ChatUserRole = "viewer"
+	ChatUserRoleSubscriber  ChatUserRole = "subscriber"
+	ChatUserRoleVIP         ChatUserRole = "vip"
+	ChatUserRoleModerator   ChatUserRole = "moderator"
+	ChatUserRoleBroadcaster ChatUserRole = "broadcaster"
+)
+
+var chatUserRoleRanks = map[ChatUserRole]int{
+	ChatUserRoleViewer:      0,
+	ChatUserRoleSubscriber:  1,
+	ChatUserRoleVIP:         2,
+	ChatUserRoleModerator:   3,
+	ChatUserRoleBroadcaster: 4,
+}
+
+// Bots de chat mais comuns nas três plataformas
+var knownChatBots = []string{
+	"nightbot",
+	"streamelements",
+	"streamlabs",
+	"moobot",
+	"fossabot",
+	"wizebot",
+	"botrix",
+	"botrixoficial",
+	"kickbot",
+	"sery_bot",
+	"soundalerts",
+	"commanderroot",
+}
+
+// Rank devolve a posição do cargo, cargos desconhecidos valem como viewer
+func (r ChatUserRole) Rank() int {
+	return chatUserRoleRanks[r]
+}
+
+func IsValidChatUserRole(role string) bool {
+	_, ok := chatUserRoleRanks[ChatUserRole(role)]
+	return ok
+}
+
+// GetRoleFromBadges deduz o cargo pelas badges, o Type vem de cada plataforma ("OWNER" no YouTube,
+// "custom" nas badges da Twitch) então o nome da badge também é considerado
+func GetRoleFromBadges(badges []ChatUserBadge) ChatUserRole {
+	role := ChatUserRoleViewer
+	for _, badge := range badges {
+		badgeRole := getBadgeRole(badge)
+		if badgeRole.Rank() > role.Rank() {
+			role = badgeRole
+		}
+	}
+	return role
+}
+
+func getBadgeRole(badge ChatUserBadge) ChatUserRole {
+	badgeType := strings.ToLower(badge.Type)
+	name := strings.ToLower(badge.Name)
+	switch {
+	case badgeType == "broadcaster" || badgeType == "owner" || strings.Contains(name, "broadcaster"):
+		return ChatUserRoleBroadcaster
+	case badgeType == "moderator" || strings.Contains(name, "moderator"):
+		return ChatUserRoleModerator
+	case badgeType == "vip" || name == "vip":
+		return ChatUserRoleVIP
+	case badgeType == "subscriber" || badgeType == "founder" || strings.Contains(name, "subscriber") ||
+		strings.Contains(name, "founder") || strings.Contains(name, "member"):
+		return ChatUserRoleSubscriber
+	}
+	return ChatUserRoleViewer
+}
+
+func IsKnownChatBot(userName string) bool {
+	userName = strings.ToLower(strings.TrimPrefix(userName, "@"))
+	for _, bot := range knownChatBots {
+		if userName == bot {
+			return true
+		}
+	}
+	return false
+}
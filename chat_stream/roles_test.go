@@ -0,0 +1,47 @@
+package chat_stream
+
+import "testing"
+
+func TestTwitchMessageRoleFromBadges(t *testing.T) {
+	con := &TWChatStreamCon{badgesDB: getDefaultBadges(), cheermotesDB: getDefaultCheermotes()}
+	cases := []struct {
+		badges string
+		want   ChatUserRole
+	}{
+		{"broadcaster/1,subscriber/0", ChatUserRoleBroadcaster},
+		{"moderator/1", ChatUserRoleModerator},
+		{"vip/1", ChatUserRoleVIP},
+		{"founder/0", ChatUserRoleSubscriber},
+		{"premium/1", ChatUserRoleViewer},
+		{"", ChatUserRoleViewer},
+	}
+	for _, c := range cases {
+		line := "@badge-info=;badges=" + c.badges + ";display-name=Canal;id=abc;tmi-sent-ts=1700000000000;user-id=42 " +
+			":canal!canal@canal.tmi.twitch.tv PRIVMSG #canal :oi chat"
+		message, err := parseTwMessage(con, line)
+		if err != nil {
+			t.Fatalf("badges %q: %v", c.badges, err)
+		}
+		if got := GetRoleFromBadges(message.Badges); got != c.want {
+			t.Errorf("badges %q: role = %s, want %s", c.badges, got, c.want)
+		}
+	}
+}
+
+func TestRoleFromPlatformBadges(t *testing.T) {
+	cases := []struct {
+		badge ChatUserBadge
+		want  ChatUserRole
+	}{
+		{ChatUserBadge{Type: "OWNER", Name: "Owner"}, ChatUserRoleBroadcaster},
+		{ChatUserBadge{Type: "broadcaster", Name: "Broadcaster"}, ChatUserRoleBroadcaster},
+		{ChatUserBadge{Type: "MODERATOR", Name: "Moderator"}, ChatUserRoleModerator},
+		{ChatUserBadge{Type: "custom", Name: "Member (6 months)"}, ChatUserRoleSubscriber},
+		{ChatUserBadge{Type: "custom", Name: "Verified"}, ChatUserRoleViewer},
+	}
+	for _, c := range cases {
+		if got := GetRoleFromBadges([]ChatUserBadge{c.badge}); got != c.want {
+			t.Errorf("%+v: role = %s, want %s", c.badge, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package chat_stream
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Opcodes da EventAPI v3 do 7TV
+const (
+	sevenTVOpDispatch     = 0
+	sevenTVOpHello        = 1
+	sevenTVOpHeartbeat    = 2
+	sevenTVOpReconnect    = 4
+	sevenTVOpEndOfStream  = 7
+	sevenTVOpSubscribe    = 35
+	sevenTVOpUnsubscribe  = 36
+	sevenTVReconnectDelay = 5 * time.Second
+	sevenTVMaxBackoff     = 2 * time.Minute
+)
+
+type sevenTVChangeHandler func(channelKey string, removedName string, addedName string, addedImgUrl string)
+
+// sevenTVEventSubscriber mantém uma única conexão com a EventAPI do 7TV, inscrita nos conjuntos
+// de emotes de todos os canais conectados, reconectando sozinha se a conexão cair
+type sevenTVEventSubscriber struct {
+	url      string
+	onChange sevenTVChangeHandler
+	mu       sync.Mutex
+	ws       *websocket.Conn
+	sets     map[string]string // id do conjunto -> "plataforma/usuário"
+	running  bool
+	closed   bool
+}
+
+func newSevenTVEventSubscriber(url string, onChange sevenTVChangeHandler) *sevenTVEventSubscriber {
+	return &sevenTVEventSubscriber{
+		url:      url,
+		onChange: onChange,
+		sets:     map[string]string{},
+	}
+}
+
+func (s *sevenTVEventSubscriber) Subscribe(setId string, channelKey string) {
+	if s.url == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if _, ok := s.sets[setId]; ok {
+		s.sets[setId] = channelKey
+		return
+	}
+	s.sets[setId] = channelKey
+	if !s.running {
+		s.running = true
+		go s.run()
+		return
+	}
+	if s.ws != nil {
+		s.writeSubscription(sevenTVOpSubscribe, setId)
+	}
+}
+
+func (s *sevenTVEventSubscriber) UnsubscribeChannel(channelKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for setId, key := range s.sets {
+		if key != channelKey {
+			continue
+		}
+		delete(s.sets, setId)
+		if s.ws != nil {
+			s.writeSubscription(sevenTVOpUnsubscribe, setId)
+		}
+	}
+}
+
+func (s *sevenTVEventSubscriber) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.ws != nil {
+		s.ws.Close()
+		s.ws = nil
+	}
+}
+
+// writeSubscription deve ser chamado com mu travado
+func (s *sevenTVEventSubscriber) writeSubscription(op int, setId string) {
+	err := s.ws.WriteJSON(map[string]any{
+		"op": op,
+		"d": map[string]any{
+			"type":      "emote_set.update",
+			"condition": map[string]any{"object_id": setId},
+		},
+	})
+	if err != nil {
+		log.Println("[sevenTVEventSubscriber] Fail to send subscription for", setId, err)
+	}
+}
+
+func (s *sevenTVEventSubscriber) run() {
+	backoff := sevenTVReconnectDelay
+	for {
+		s.mu.Lock()
+		if s.closed || len(s.sets) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+
+		startedAt := time.Now()
+		err := s.connectAndListen()
+		if err != nil {
+			log.Println("[sevenTVEventSubscriber] Connection lost:", err)
+		}
+		if time.Since(startedAt) > sevenTVMaxBackoff {
+			backoff = sevenTVReconnectDelay
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, sevenTVMaxBackoff)
+	}
+}
+
+func (s *sevenTVEventSubscriber) connectAndListen() error {
+	ws, _, err := websocket.DefaultDialer.Dial(s.url, nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	heartbeatTimeout := 90 * time.Second
+	for {
+		ws.SetReadDeadline(time.Now().Add(heartbeatTimeout))
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			s.clearConnection(ws)
+			return err
+		}
+		var payload map[string]any
+		err = json.Unmarshal(message, &payload)
+		if err != nil {
+			log.Println("[sevenTVEventSubscriber] Fail to parse message", err)
+			continue
+		}
+		op, _ := payload["op"].(float64)
+		data, _ := payload["d"].(map[string]any)
+
+		switch int(op) {
+		case sevenTVOpHello:
+			// O servidor manda heartbeats no intervalo informado, sem eles por 3 intervalos a conexão é dada como morta
+			if interval, ok := data["heartbeat_interval"].(float64); ok && interval > 0 {
+				heartbeatTimeout = 3 * time.Duration(interval) * time.Millisecond
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				return nil
+			}
+			s.ws = ws
+			for setId := range s.sets {
+				s.writeSubscription(sevenTVOpSubscribe, setId)
+			}
+			s.mu.Unlock()
+		case sevenTVOpDispatch:
+			s.handleDispatch(data)
+		case sevenTVOpReconnect, sevenTVOpEndOfStream:
+			s.clearConnection(ws)
+			return &CustomError{message: "7TV asked to reconnect"}
+		case sevenTVOpHeartbeat:
+			// Nada a fazer, o prazo de leitura já foi renovado
+		}
+	}
+}
+
+func (s *sevenTVEventSubscriber) clearConnection(ws *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ws == ws {
+		s.ws = nil
+	}
+}
+
+func (s *sevenTVEventSubscriber) handleDispatch(data map[string]any) {
+	if data["type"] != "emote_set.update" {
+		return
+	}
+	body, ok := data["body"].(map[string]any)
+	if !ok {
+		return
+	}
+	setId, _ := body["id"].(string)
+	s.mu.Lock()
+	channelKey, ok := s.sets[setId]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, change := range getSevenTVEmoteChanges(body, "pushed") {
+		if name, imgUrl, ok := getSevenTVEmote(change["value"]); ok {
+			s.onChange(channelKey, "", name, imgUrl)
+		}
+	}
+	for _, change := range getSevenTVEmoteChanges(body, "pulled") {
+		if name, _ := change["old_value"]["name"].(string); name != "" {
+			s.onChange(channelKey, name, "", "")
+		}
+	}
+	for _, change := range getSevenTVEmoteChanges(body, "updated") {
+		oldName, _ := change["old_value"]["name"].(string)
+		if name, imgUrl, ok := getSevenTVEmote(change["value"]); ok {
+			s.onChange(channelKey, oldName, name, imgUrl)
+		}
+	}
+}
+
+// getSevenTVEmoteChanges devolve as mudanças da lista de emotes com value e old_value já convertidos
+func getSevenTVEmoteChanges(body map[string]any, field string) []map[string]map[string]any {
+	changes := []map[string]map[string]any{}
+	list, _ := body[field].([]any)
+	for _, item := range list {
+		change, ok := item.(map[string]any)
+		if !ok || change["key"] != "emotes" {
+			continue
+		}
+		value, _ := change["value"].(map[string]any)
+		oldValue, _ := change["old_value"].(map[string]any)
+		changes = append(changes, map[string]map[string]any{
+			"value":     value,
+			"old_value": oldValue,
+		})
+	}
+	return changes
+}
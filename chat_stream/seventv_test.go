@@ -0,0 +1,123 @@
+package chat_stream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type fakeSevenTVSubscription struct {
+	op    float64
+	setId string
+}
+
+// fakeSevenTVEventAPI faz o papel da EventAPI: manda o hello, repassa as inscrições recebidas e
+// envia os dispatches assim que o primeiro conjunto é inscrito
+func fakeSevenTVEventAPI(t *testing.T, dispatches []map[string]any) (*httptest.Server, <-chan fakeSevenTVSubscription) {
+	subscriptions := make(chan fakeSevenTVSubscription, 10)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(map[string]any{"op": sevenTVOpHello, "d": map[string]any{"heartbeat_interval": 45000}})
+		sentDispatches := false
+		for {
+			var message map[string]any
+			if err := conn.ReadJSON(&message); err != nil {
+				return
+			}
+			op, _ := message["op"].(float64)
+			setId, _ := GetDeepMapValue(message, []any{"d", "condition", "object_id"}, true)
+			id, _ := setId.(string)
+			subscriptions <- fakeSevenTVSubscription{op: op, setId: id}
+			if sentDispatches {
+				continue
+			}
+			sentDispatches = true
+			for _, dispatch := range dispatches {
+				conn.WriteJSON(map[string]any{"op": sevenTVOpDispatch, "d": dispatch})
+			}
+		}
+	}))
+	return server, subscriptions
+}
+
+func waitSevenTVSubscription(t *testing.T, subscriptions <-chan fakeSevenTVSubscription) fakeSevenTVSubscription {
+	select {
+	case subscription := <-subscriptions:
+		return subscription
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for 7TV subscription")
+		return fakeSevenTVSubscription{}
+	}
+}
+
+func TestSevenTVEventAPIAppliesEmoteSetChanges(t *testing.T) {
+	events, subscriptions := fakeSevenTVEventAPI(t, []map[string]any{
+		{"type": "emote_set.update", "body": map[string]any{
+			"id":     "set123",
+			"pushed": []any{map[string]any{"key": "emotes", "value": fakeSevenTVEmote("NewEmote", "n1")}},
+			"pulled": []any{map[string]any{"key": "emotes", "old_value": map[string]any{"name": "Removed"}}},
+			"updated": []any{map[string]any{
+				"key":       "emotes",
+				"old_value": map[string]any{"name": "Before"},
+				"value":     fakeSevenTVEmote("After", "a1"),
+			}},
+		}},
+		// Conjunto de outro canal, não inscrito, deve ser ignorado
+		{"type": "emote_set.update", "body": map[string]any{
+			"id":     "other",
+			"pushed": []any{map[string]any{"key": "emotes", "value": fakeSevenTVEmote("Ignored", "i1")}},
+		}},
+	})
+	defer events.Close()
+	api := fakeEmoteServer(map[string]any{
+		"/7tv/users/twitch/123": map[string]any{"emote_set": map[string]any{
+			"id":     "set123",
+			"emotes": []any{fakeSevenTVEmote("Removed", "r1"), fakeSevenTVEmote("Before", "b1")},
+		}},
+	})
+	defer api.Close()
+	provider := newTestEmoteProvider(api, "ws"+strings.TrimPrefix(events.URL, "http"))
+	defer provider.Stop()
+
+	provider.AddChannel(PlatformTypeTwitch, "123")
+	if subscription := waitSevenTVSubscription(t, subscriptions); subscription.op != sevenTVOpSubscribe || subscription.setId != "set123" {
+		t.Fatalf("unexpected subscription %+v", subscription)
+	}
+
+	expected := map[string]string{
+		"NewEmote": "https://cdn.7tv.app/emote/n1/2x.webp",
+		"After":    "https://cdn.7tv.app/emote/a1/2x.webp",
+	}
+	var got map[string]string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		got = resolveEmotes(provider, "123", "NewEmote After Before Removed Ignored")
+		if len(got) == len(expected) && got["NewEmote"] == expected["NewEmote"] && got["After"] == expected["After"] {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("resolved %v, want %v", got, expected)
+	}
+	for code, imgUrl := range expected {
+		if got[code] != imgUrl {
+			t.Errorf("%s = %q, want %q", code, got[code], imgUrl)
+		}
+	}
+
+	provider.RemoveChannel(PlatformTypeTwitch, "123")
+	if subscription := waitSevenTVSubscription(t, subscriptions); subscription.op != sevenTVOpUnsubscribe || subscription.setId != "set123" {
+		t.Errorf("unexpected unsubscription %+v", subscription)
+	}
+}
@@ -0,0 +1,215 @@
+package chat_stream
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SimulatorConfig controla o chat falso usado para pré-visualizar os estilos sem estar ao vivo
+type SimulatorConfig struct {
+	MessagesPerSecond float64
+	EventChance       float64 // Chance (0 a 1) de cada item gerado ser um evento em vez de mensagem
+	LongTextChance    float64 // Chance (0 a 1) de uma mensagem ter um texto longo
+	Platforms         []PlatformType
+}
+
+var DefaultSimulatorConfig = SimulatorConfig{
+	MessagesPerSecond: 1,
+	EventChance:       0.1,
+	LongTextChance:    0.15,
+	Platforms:         []PlatformType{PlatformTypeTwitch, PlatformTypeYoutube, PlatformTypeKick},
+}
+
+const (
+	SimulatorMinMessagesPerSecond = 0.2
+	SimulatorMaxMessagesPerSecond = 10
+)
+
+var simulatorNames = []string{
+	"gamer_do_rio", "MariaStreams", "xX_NoobMaster_Xx", "CafeComCodigo", "luquinhas2009",
+	"TioDoPave", "pixel_witch", "Nightbot", "AnaClara_", "BigodeGrosso",
+	"frangoassado", "ProPlayerBR", "zezinho", "CrazyCatLady", "o_brabo",
+}
+
+var simulatorTexts = []string{
+	"boa noite chat!", "KKKKKKKK", "que jogada!", "primeira vez aqui, curti demais",
+	"alguém sabe qual é a música?", "GG", "F", "manda salve pro pai", "isso foi insano",
+	"bora bora bora", "olha o lag", "quem tá vendo do celular?", "essa skin é muito boa",
+}
+
+var simulatorLongTexts = []string{
+	"Chat, deixa eu contar uma coisa: acompanho essa live desde o começo e nunca vi uma partida tão bem jogada quanto essa, parabéns demais pelo trabalho e pela comunidade que vocês construíram aqui!",
+	"Alguém mais reparou que no último round o time inteiro rushou o mesmo lado sem comunicação nenhuma? Foi uma mistura de genialidade com sorte que eu não consigo nem explicar direito",
+	"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+}
+
+var simulatorEmotes = []ChatStreamMessagePart{
+	{PartType: ChatStreamMessagePartTypeEmote, EmoteName: "Kappa", EmoteImgUrl: "https://static-cdn.jtvnw.net/emoticons/v2/25/default/dark/3.0"},
+	{PartType: ChatStreamMessagePartTypeEmote, EmoteName: "LUL", EmoteImgUrl: "https://static-cdn.jtvnw.net/emoticons/v2/425618/default/dark/3.0"},
+	{PartType: ChatStreamMessagePartTypeEmote, EmoteName: "PogChamp", EmoteImgUrl: "https://static-cdn.jtvnw.net/emoticons/v2/305954156/default/dark/3.0"},
+	{PartType: ChatStreamMessagePartTypeEmote, EmoteName: "BibleThump", EmoteImgUrl: "https://static-cdn.jtvnw.net/emoticons/v2/86/default/dark/3.0"},
+	{PartType: ChatStreamMessagePartTypeEmote, EmoteName: "HeyGuys", EmoteImgUrl: "https://static-cdn.jtvnw.net/emoticons/v2/30259/default/dark/3.0"},
+}
+
+var simulatorBadges = []ChatUserBadge{
+	{Name: "Broadcaster", ImgSrc: "https://static-cdn.jtvnw.net/badges/v1/5527c58c-fb7d-422d-b71b-f309dcb85cc1/3", Type: "broadcaster"},
+	{Name: "Moderator", ImgSrc: "https://static-cdn.jtvnw.net/badges/v1/3267646d-33f0-4b17-b3df-f923a41db1d0/3", Type: "moderator"},
+	{Name: "VIP", ImgSrc: "https://static-cdn.jtvnw.net/badges/v1/b817aba4-fad8-49e2-b88a-7cc744dfa6ec/3", Type: "vip"},
+	{Name: "Founder", ImgSrc: "https://static-cdn.jtvnw.net/badges/v1/511b78a9-ab37-472f-9569-457753bbe7d3/3", Type: "founder"},
+}
+
+// ConnectToSimulatedChat cria uma conexão que gera mensagens e eventos falsos no ritmo configurado
+func ConnectToSimulatedChat(config SimulatorConfig) (ChatStreamCon, error) {
+	if len(config.Platforms) == 0 {
+		return nil, &CustomError{message: "Simulator needs at least one platform"}
+	}
+	con := &SimulatorChatStreamCon{
+		config:    config,
+		stream:    make(chan ChatStreamMessage, ChatStreamMessageBufferSize),
+		events:    make(chan ChatStreamEvent, ChatStreamMessageBufferSize),
+		deletions: make(chan ChatStreamDeletion, ChatStreamMessageBufferSize),
+		done:      make(chan struct{}),
+	}
+	con.SetMessagesPerSecond(config.MessagesPerSecond)
+
+	log.Println("Starting simulated chat at", config.MessagesPerSecond, "messages per second")
+	go func() {
+		// Só esta goroutine envia nos canais, então só ela os fecha, depois do Close sinalizar pelo done
+		defer close(con.stream)
+		defer close(con.events)
+		defer close(con.deletions)
+		random := rand.New(rand.NewSource(time.Now().UnixNano()))
+		var counter uint64 = 0
+		for {
+			counter++
+			if random.Float64() < con.config.EventChance {
+				event := generateSimulatedEvent(con, random)
+				select {
+				case con.events <- event:
+				case <-con.done:
+				default:
+					log.Println("Chat stream buffer is full, dropping event:", event)
+				}
+			} else {
+				msg := generateSimulatedMessage(con, random, counter)
+				select {
+				case con.stream <- msg:
+				case <-con.done:
+				default:
+					log.Println("Chat stream buffer is full, dropping message:", msg)
+				}
+			}
+			select {
+			case <-con.done:
+				log.Println("Simulated chat closed, stopping generation")
+				return
+			case <-time.After(con.getInterval()):
+			}
+		}
+	}()
+	return con, nil
+}
+
+func generateSimulatedMessage(con *SimulatorChatStreamCon, random *rand.Rand, counter uint64) ChatStreamMessage {
+	name := pickRandom(random, simulatorNames)
+	msg := ChatStreamMessage{
+		Platform:     pickRandom(random, con.config.Platforms),
+		Id:           "sim-" + strconv.FormatUint(counter, 10),
+		UserId:       "sim-" + strings.ToLower(name),
+		Name:         name,
+		MessageParts: generateSimulatedMessageParts(con, random),
+		Timestamp:    time.Now().Unix(),
+		Badges:       []ChatUserBadge{},
+	}
+	if random.Float64() < 0.35 {
+		msg.Badges = append(msg.Badges, pickRandom(random, simulatorBadges))
+	}
+	if msg.Platform == PlatformTypeTwitch && random.Float64() < 0.05 {
+		msg.Bits = []int{1, 100, 1000, 5000}[random.Intn(4)]
+		cheerWord := "Cheer" + strconv.Itoa(msg.Bits)
+		imgUrl, _ := getCheermoteImgUrl(&TWChatStreamCon{cheermotesDB: getDefaultCheermotes()}, cheerWord)
+		msg.MessageParts = append([]ChatStreamMessagePart{{
+			PartType:    ChatStreamMessagePartTypeEmote,
+			EmoteName:   cheerWord,
+			EmoteImgUrl: imgUrl,
+		}}, msg.MessageParts...)
+	}
+	return msg
+}
+
+func generateSimulatedMessageParts(con *SimulatorChatStreamCon, random *rand.Rand) []ChatStreamMessagePart {
+	text := pickRandom(random, simulatorTexts)
+	if random.Float64() < con.config.LongTextChance {
+		text = pickRandom(random, simulatorLongTexts)
+	}
+	parts := []ChatStreamMessagePart{{
+		PartType: ChatStreamMessagePartTypeText,
+		Text:     text,
+	}}
+	for i := random.Intn(4); i > 0; i-- {
+		parts = append(parts,
+			ChatStreamMessagePart{PartType: ChatStreamMessagePartTypeText, Text: " "},
+			pickRandom(random, simulatorEmotes),
+		)
+	}
+	return parts
+}
+
+func generateSimulatedEvent(con *SimulatorChatStreamCon, random *rand.Rand) ChatStreamEvent {
+	name := pickRandom(random, simulatorNames)
+	event := ChatStreamEvent{
+		Name:         name,
+		MessageParts: []ChatStreamMessagePart{},
+		Timestamp:    time.Now().Unix(),
+		Badges:       []ChatUserBadge{},
+	}
+
+	switch pickRandom(random, con.config.Platforms) {
+	case PlatformTypeYoutube:
+		event.Platform = PlatformTypeYoutube
+		if random.Float64() < 0.5 {
+			event.EventType = ChatStreamEventTypeMembership
+			event.SystemText = "Bem-vindo(a) a Membros!"
+			return event
+		}
+		event.EventType = ChatStreamEventTypeSuperChat
+		event.Currency = "R$"
+		event.Amount = []string{"5,00", "20,00", "50,00", "100,00"}[random.Intn(4)]
+		event.SystemText = event.Currency + " " + event.Amount
+		event.HeaderColor = "#E62117FF"
+		event.BodyColor = "#E62117CC"
+		event.MessageParts = generateSimulatedMessageParts(con, random)
+	default:
+		event.Platform = PlatformTypeTwitch
+		switch random.Intn(3) {
+		case 0:
+			event.EventType = ChatStreamEventTypeResub
+			event.Months = 1 + random.Intn(48)
+			event.Tier = "1000"
+			event.SystemText = name + " se inscreveu no Nível 1. Inscrito há " + strconv.Itoa(event.Months) + " meses!"
+			event.MessageParts = generateSimulatedMessageParts(con, random)
+		case 1:
+			event.EventType = ChatStreamEventTypeSubGift
+			event.Tier = "1000"
+			event.Recipient = pickRandom(random, simulatorNames)
+			event.SystemText = name + " deu uma inscrição de Nível 1 para " + event.Recipient + "!"
+		default:
+			event.EventType = ChatStreamEventTypeRaid
+			event.ViewerCount = 5 + random.Intn(500)
+			event.SystemText = strconv.Itoa(event.ViewerCount) + " espectadores estão reidando a partir de " + name + "!"
+		}
+	}
+	return event
+}
+
+func pickRandom[T any](random *rand.Rand, list []T) T {
+	return list[random.Intn(len(list))]
+}
+
+func clampSimulatorRate(messagesPerSecond float64) float64 {
+	return math.Min(math.Max(messagesPerSecond, SimulatorMinMessagesPerSecond), SimulatorMaxMessagesPerSecond)
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"net/http"
 	"net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,6 +16,8 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+var cheerTokenRegex = regexp.MustCompile(`^([A-Za-z]+)(\d+)$`)
+
 func ConnectToTwitchChat(channelID string) (ChatStreamCon, error) {
 	return generateTwChatStream(channelID)
 }
@@ -24,6 +27,9 @@ func generateTwChatStream(channelID string) (ChatStreamCon, error) {
 	con := &TWChatStreamCon{
 		ChannelID: channelID,
 		stream:    make(chan ChatStreamMessage, ChatStreamMessageBufferSize),
+		events:    make(chan ChatStreamEvent, ChatStreamMessageBufferSize),
+		deletions: make(chan ChatStreamDeletion, ChatStreamMessageBufferSize),
+		done:      make(chan struct{}),
 	}
 
 	u := url.URL{Scheme: "wss", Host: "irc-ws.chat.twitch.tv", Path: "/"}
@@ -79,13 +85,26 @@ func initTwitchChatStream(con *TWChatStreamCon) error {
 	}
 
 	fillBadgesDatabase(con)
+	fillCheermotesDatabase(con)
 
 	return nil
 }
 
 func fillBadgesDatabase(con *TWChatStreamCon) {
+	con.badgesDB = getDefaultBadges()
+
+	fillCustomBadgesDatabase(con)
+}
+
+// getDefaultBadges devolve as badges globais que não dependem do canal
+func getDefaultBadges() map[string]ChatUserBadge {
 	var badges map[string]ChatUserBadge = map[string]ChatUserBadge{}
 
+	badges["broadcaster/1"] = ChatUserBadge{
+		Name:   "Broadcaster",
+		ImgSrc: "https://static-cdn.jtvnw.net/badges/v1/5527c58c-fb7d-422d-b71b-f309dcb85cc1/3",
+		Type:   "broadcaster",
+	}
 	badges["moderator/1"] = ChatUserBadge{
 		Name:   "Moderator",
 		ImgSrc: "https://static-cdn.jtvnw.net/badges/v1/3267646d-33f0-4b17-b3df-f923a41db1d0/3",
@@ -117,9 +136,7 @@ func fillBadgesDatabase(con *TWChatStreamCon) {
 		Type:   "prime",
 	}
 
-	con.badgesDB = badges
-
-	fillCustomBadgesDatabase(con)
+	return badges
 }
 
 func fillCustomBadgesDatabase(con *TWChatStreamCon) {
@@ -200,12 +217,136 @@ func fillCustomBadgesDatabase(con *TWChatStreamCon) {
 	}
 }
 
+func fillCheermotesDatabase(con *TWChatStreamCon) {
+	con.cheermotesDB = getDefaultCheermotes()
+
+	fillCustomCheermotesDatabase(con)
+}
+
+func getDefaultCheermotes() map[string]TWCheermote {
+	cheermotes := map[string]TWCheermote{}
+
+	cheermotes["cheer"] = TWCheermote{
+		Prefix:      "Cheer",
+		Tiers:       []int{1, 100, 1000, 5000, 10000, 100000},
+		TemplateURL: "https://d3aqoihi2n8ty8.cloudfront.net/actions/PREFIX/BACKGROUND/ANIMATION/TIER/SCALE.EXTENSION",
+	}
+
+	return cheermotes
+}
+
+func fillCustomCheermotesDatabase(con *TWChatStreamCon) {
+	cheerGroupFields := "templateURL nodes { prefix tiers { bits } }"
+	reqData := map[string]any{
+		"query": "query { cheerConfig { groups { " + cheerGroupFields + " } } " +
+			"user(login: " + strconv.Quote(con.ChannelID) + ") { cheer { cheerGroups { " + cheerGroupFields + " } } } }",
+	}
+	jsonBytes, err := json.Marshal(reqData)
+	if err != nil {
+		log.Println("Error marshaling JSON for Twitch cheermotes:", err)
+		return
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://gql.twitch.tv/gql", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("client-id", "kimne78kx3ncx6brgo4mv6wki5h1ko")
+	req.Body = io.NopCloser(strings.NewReader(string(jsonBytes)))
+
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+	resp, err := client.Do(req)
+
+	if err != nil {
+		log.Println("Fail to get cheermotes from channel", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Println("Fail to get cheermotes from channel, status: ", resp.StatusCode)
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Println("Error parsing JSON from Twitch cheermotes:", err)
+		return
+	}
+
+	var data map[string]any
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		log.Println("Error unmarshaling JSON from Twitch cheermotes:", err)
+		return
+	}
+
+	globalGroups, ok := GetDeepMapValue(data, []any{"data", "cheerConfig", "groups"}, false)
+	if ok {
+		addCheermoteGroups(con, globalGroups)
+	}
+	// Cheermotes do canal são adicionados por último para terem prioridade sobre os globais
+	channelGroups, ok := GetDeepMapValue(data, []any{"data", "user", "cheer", "cheerGroups"}, true)
+	if ok {
+		addCheermoteGroups(con, channelGroups)
+	}
+}
+
+func addCheermoteGroups(con *TWChatStreamCon, groups any) {
+	groupList, ok := groups.([]any)
+	if !ok {
+		return
+	}
+	for _, group := range groupList {
+		groupData, ok := group.(map[string]any)
+		if !ok {
+			continue
+		}
+		templateURL, ok := groupData["templateURL"].(string)
+		if !ok || templateURL == "" {
+			continue
+		}
+		nodes, ok := groupData["nodes"].([]any)
+		if !ok {
+			continue
+		}
+		for _, node := range nodes {
+			nodeData, ok := node.(map[string]any)
+			if !ok {
+				continue
+			}
+			prefix, ok := nodeData["prefix"].(string)
+			if !ok || prefix == "" {
+				continue
+			}
+			tiers := []int{}
+			tierList, _ := nodeData["tiers"].([]any)
+			for _, tier := range tierList {
+				tierData, ok := tier.(map[string]any)
+				if !ok {
+					continue
+				}
+				if bits, ok := tierData["bits"].(float64); ok {
+					tiers = append(tiers, int(bits))
+				}
+			}
+			sort.Ints(tiers)
+			con.cheermotesDB[strings.ToLower(prefix)] = TWCheermote{
+				Prefix:      prefix,
+				Tiers:       tiers,
+				TemplateURL: templateURL,
+			}
+		}
+	}
+}
+
+// iterateOnTwMessages é a única que envia nos canais da conexão, então só ela os fecha
 func iterateOnTwMessages(con *TWChatStreamCon) {
+	defer close(con.stream)
+	defer close(con.events)
+	defer close(con.deletions)
 	pingTimerval := time.NewTicker(30 * time.Second)
 	defer pingTimerval.Stop()
 	for {
 		if !con.IsConnected() {
-			con.Close()
 			return
 		}
 		select {
@@ -217,16 +358,73 @@ func iterateOnTwMessages(con *TWChatStreamCon) {
 
 		_, message, err := con.ws.ReadMessage()
 		if err != nil {
-			log.Println(err)
+			if con.IsConnected() {
+				log.Println(err)
+			}
 			con.Close()
 			return
 		}
-		parsed, err := parseTwMessage(con, string(message))
+		// Um único frame pode conter várias linhas IRC
+		for line := range strings.SplitSeq(string(message), "\r\n") {
+			if line == "" || !con.IsConnected() {
+				continue
+			}
+			handleTwLine(con, line)
+		}
+	}
+}
+
+func handleTwLine(con *TWChatStreamCon, line string) {
+	switch getTwCommand(line) {
+	case "PRIVMSG":
+		parsed, err := parseTwMessage(con, line)
 		if err != nil || parsed == nil {
-			continue
+			return
+		}
+		select {
+		case con.stream <- *parsed:
+		case <-con.done:
 		}
-		con.stream <- *parsed
+	case "USERNOTICE":
+		parsed, err := parseTwUserNotice(con, line)
+		if err != nil || parsed == nil {
+			return
+		}
+		select {
+		case con.events <- *parsed:
+		case <-con.done:
+		}
+	case "CLEARCHAT", "CLEARMSG":
+		parsed, err := parseTwDeletion(line)
+		if err != nil || parsed == nil {
+			return
+		}
+		select {
+		case con.deletions <- *parsed:
+		case <-con.done:
+		}
+	}
+}
+
+// getTwCommand retorna o comando IRC de uma linha, ignorando as tags e o prefixo
+func getTwCommand(line string) string {
+	rest := line
+	if strings.HasPrefix(rest, "@") {
+		index := strings.Index(rest, " ")
+		if index < 0 {
+			return ""
+		}
+		rest = rest[index+1:]
+	}
+	if strings.HasPrefix(rest, ":") {
+		index := strings.Index(rest, " ")
+		if index < 0 {
+			return ""
+		}
+		rest = rest[index+1:]
 	}
+	command, _, _ := strings.Cut(rest, " ")
+	return command
 }
 
 func parseTwMessage(con *TWChatStreamCon, message string) (*ChatStreamMessage, error) {
@@ -248,17 +446,198 @@ func parseTwMessage(con *TWChatStreamCon, message string) (*ChatStreamMessage, e
 		return nil, err
 	}
 
+	bits, err := strconv.Atoi(data["bits"])
+	if err == nil && bits > 0 {
+		parts = splitCheermoteParts(con, parts)
+	} else {
+		bits = 0
+	}
+
 	res := &ChatStreamMessage{
 		Platform:     PlatformTypeTwitch,
+		Id:           data["id"],
+		UserId:       data["user-id"],
+		Name:         data["display-name"],
+		MessageParts: parts,
+		Timestamp:    int64(timestamp / 1000),
+		Badges:       parseBadges(con, data),
+		Bits:         bits,
+	}
+
+	return res, nil
+}
+
+// splitCheermoteParts troca palavras como "Cheer100" nas partes de texto pela imagem do cheermote
+func splitCheermoteParts(con *TWChatStreamCon, parts []ChatStreamMessagePart) []ChatStreamMessagePart {
+	result := []ChatStreamMessagePart{}
+	for _, part := range parts {
+		if part.PartType != ChatStreamMessagePartTypeText {
+			result = append(result, part)
+			continue
+		}
+		words := strings.Split(part.Text, " ")
+		accumulator := ""
+		for i, word := range words {
+			imgUrl, ok := getCheermoteImgUrl(con, word)
+			if ok {
+				if accumulator != "" {
+					result = append(result, ChatStreamMessagePart{
+						PartType: ChatStreamMessagePartTypeText,
+						Text:     accumulator,
+					})
+					accumulator = ""
+				}
+				result = append(result, ChatStreamMessagePart{
+					PartType:    ChatStreamMessagePartTypeEmote,
+					Text:        word,
+					EmoteImgUrl: imgUrl,
+					EmoteName:   word,
+				})
+			} else {
+				accumulator += word
+			}
+			if i < len(words)-1 {
+				accumulator += " "
+			}
+		}
+		if accumulator != "" {
+			result = append(result, ChatStreamMessagePart{
+				PartType: ChatStreamMessagePartTypeText,
+				Text:     accumulator,
+			})
+		}
+	}
+	return result
+}
+
+func getCheermoteImgUrl(con *TWChatStreamCon, word string) (string, bool) {
+	match := cheerTokenRegex.FindStringSubmatch(word)
+	if match == nil {
+		return "", false
+	}
+	cheermote, ok := con.cheermotesDB[strings.ToLower(match[1])]
+	if !ok || len(cheermote.Tiers) == 0 {
+		return "", false
+	}
+	amount, err := strconv.Atoi(match[2])
+	if err != nil || amount <= 0 {
+		return "", false
+	}
+
+	tier := cheermote.Tiers[0]
+	for _, currentTier := range cheermote.Tiers {
+		if currentTier <= amount {
+			tier = currentTier
+		}
+	}
+
+	imgUrl := strings.NewReplacer(
+		"PREFIX", strings.ToLower(cheermote.Prefix),
+		"BACKGROUND", "dark",
+		"ANIMATION", "animated",
+		"TIER", strconv.Itoa(tier),
+		"SCALE", "2",
+		"EXTENSION", "gif",
+	).Replace(cheermote.TemplateURL)
+	return imgUrl, true
+}
+
+func parseTwUserNotice(con *TWChatStreamCon, message string) (*ChatStreamEvent, error) {
+	data, err := explodeTwCommand(message, "USERNOTICE")
+	if err != nil {
+		return nil, err
+	}
+
+	eventType := ChatStreamEventType(data["msg-id"])
+	switch eventType {
+	case ChatStreamEventTypeSub,
+		ChatStreamEventTypeResub,
+		ChatStreamEventTypeSubGift,
+		ChatStreamEventTypeSubMysteryGift,
+		ChatStreamEventTypeRaid,
+		ChatStreamEventTypeAnnouncement:
+	default:
+		return nil, nil // Tipos de USERNOTICE não suportados
+	}
+
+	timestamp, err := strconv.Atoi(data["tmi-sent-ts"])
+	if err != nil {
+		timestamp = int(time.Now().UnixMilli())
+	}
+
+	parts, err := parseMessageParts(data["message"], data["emotes"])
+	if err != nil {
+		log.Println("Error parsing USERNOTICE message parts:", err)
+		parts = []ChatStreamMessagePart{}
+	}
+
+	res := &ChatStreamEvent{
+		Platform:     PlatformTypeTwitch,
+		EventType:    eventType,
+		UserId:       data["user-id"],
 		Name:         data["display-name"],
+		SystemText:   unescapeTwTagValue(data["system-msg"]),
 		MessageParts: parts,
 		Timestamp:    int64(timestamp / 1000),
 		Badges:       parseBadges(con, data),
+		Tier:         data["msg-param-sub-plan"],
+		Recipient:    unescapeTwTagValue(data["msg-param-recipient-display-name"]),
+		Color:        data["msg-param-color"],
+	}
+
+	if months, err := strconv.Atoi(data["msg-param-cumulative-months"]); err == nil {
+		res.Months = months
+	} else if months, err := strconv.Atoi(data["msg-param-months"]); err == nil {
+		res.Months = months
+	}
+	if eventType == ChatStreamEventTypeSubGift || eventType == ChatStreamEventTypeSubMysteryGift {
+		res.Gifter = data["display-name"]
+	}
+	if giftCount, err := strconv.Atoi(data["msg-param-mass-gift-count"]); err == nil {
+		res.GiftCount = giftCount
+	}
+	if viewerCount, err := strconv.Atoi(data["msg-param-viewerCount"]); err == nil {
+		res.ViewerCount = viewerCount
+	}
+	if eventType == ChatStreamEventTypeRaid && res.Name == "" {
+		res.Name = unescapeTwTagValue(data["msg-param-displayName"])
 	}
 
 	return res, nil
 }
 
+func parseTwDeletion(message string) (*ChatStreamDeletion, error) {
+	command := getTwCommand(message)
+	data, err := explodeTwCommand(message, command)
+	if err != nil {
+		return nil, err
+	}
+
+	if command == "CLEARMSG" {
+		if data["target-msg-id"] == "" {
+			return nil, &CustomError{"CLEARMSG without target-msg-id"}
+		}
+		return &ChatStreamDeletion{
+			Platform:     PlatformTypeTwitch,
+			DeletionType: ChatStreamDeletionTypeMessage,
+			MessageId:    data["target-msg-id"],
+		}, nil
+	}
+
+	// CLEARCHAT sem usuário alvo significa que o chat inteiro foi limpo
+	if data["target-user-id"] == "" {
+		return &ChatStreamDeletion{
+			Platform:     PlatformTypeTwitch,
+			DeletionType: ChatStreamDeletionTypeAll,
+		}, nil
+	}
+	return &ChatStreamDeletion{
+		Platform:     PlatformTypeTwitch,
+		DeletionType: ChatStreamDeletionTypeUser,
+		UserId:       data["target-user-id"],
+	}, nil
+}
+
 func parseMessageParts(message string, emotes string) ([]ChatStreamMessagePart, error) {
 	parts := []ChatStreamMessagePart{}
 	if message == "" {
@@ -373,9 +752,13 @@ func parseBadges(con *TWChatStreamCon, metaData map[string]string) []ChatUserBad
 }
 
 func explodeTwMessage(message string) (map[string]string, error) {
-	parts := strings.Split(message, "PRIVMSG")
+	return explodeTwCommand(message, "PRIVMSG")
+}
+
+func explodeTwCommand(message string, command string) (map[string]string, error) {
+	parts := strings.SplitN(message, " "+command+" ", 2)
 	if len(parts) < 2 {
-		return nil, &CustomError{"PRIVMSG not found in message"}
+		return nil, &CustomError{command + " not found in message"}
 	}
 	metaData := strings.Split(parts[0], ";")
 	if len(parts) < 2 {
@@ -406,14 +789,39 @@ func explodeTwMessage(message string) (map[string]string, error) {
 	}
 
 	for _, line := range metaData {
-		currentEntry := strings.Split(line, "=")
-		if len(currentEntry) != 2 {
+		rawKey, rawValue, found := strings.Cut(line, "=")
+		if !found {
 			return nil, &CustomError{"Invalid metadata format, expected key=value"}
 		}
-		key := strings.TrimSpace(currentEntry[0])
-		value := strings.TrimSpace(currentEntry[1])
+		key := strings.TrimSpace(rawKey)
+		value := strings.TrimSpace(rawValue)
 		res[key] = value
 	}
 
 	return res, nil
 }
+
+// unescapeTwTagValue desfaz o escape usado pelo IRC da Twitch nos valores das tags
+func unescapeTwTagValue(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 >= len(value) {
+			builder.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 's':
+			builder.WriteByte(' ')
+		case ':':
+			builder.WriteByte(';')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'n':
+			builder.WriteByte('\n')
+		default:
+			builder.WriteByte(value[i])
+		}
+	}
+	return builder.String()
+}
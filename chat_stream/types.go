@@ -1,14 +1,31 @@
 package chat_stream
 
-import "github.com/gorilla/websocket"
+import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
 
 type PlatformType string
 
 const (
-	PlatformTypeYoutube PlatformType = "youtube"
-	PlatformTypeTwitch  PlatformType = "twitch"
+	PlatformTypeYoutube   PlatformType = "youtube"
+	PlatformTypeTwitch    PlatformType = "twitch"
+	PlatformTypeKick      PlatformType = "kick"
+	PlatformTypeReplay    PlatformType = "replay"
+	PlatformTypeSimulator PlatformType = "simulator"
 )
 
+// ChannelInvalidChars são os caracteres removidos do canal digitado, vale para a janela e para a API de controle
+var ChannelInvalidChars = map[PlatformType]*regexp.Regexp{
+	PlatformTypeYoutube: regexp.MustCompile(`[^a-zA-Z0-9_\-@:/?=&.]`),
+	PlatformTypeTwitch:  regexp.MustCompile(`[^a-zA-Z0-9_]`),
+	PlatformTypeKick:    regexp.MustCompile(`[^a-zA-Z0-9_-]`),
+}
+
 type ChatStreamMessagePartType string
 
 const (
@@ -16,6 +33,23 @@ const (
 	ChatStreamMessagePartTypeEmote ChatStreamMessagePartType = "emote"
 )
 
+type ChatStreamEventType string
+
+const (
+	ChatStreamEventTypeSub            ChatStreamEventType = "sub"
+	ChatStreamEventTypeResub          ChatStreamEventType = "resub"
+	ChatStreamEventTypeSubGift        ChatStreamEventType = "subgift"
+	ChatStreamEventTypeSubMysteryGift ChatStreamEventType = "submysterygift"
+	ChatStreamEventTypeRaid           ChatStreamEventType = "raid"
+	ChatStreamEventTypeAnnouncement   ChatStreamEventType = "announcement"
+
+	ChatStreamEventTypeSuperChat              ChatStreamEventType = "superchat"
+	ChatStreamEventTypeSuperSticker           ChatStreamEventType = "supersticker"
+	ChatStreamEventTypeMembership             ChatStreamEventType = "membership"
+	ChatStreamEventTypeMembershipGift         ChatStreamEventType = "membershipgift"
+	ChatStreamEventTypeMembershipGiftReceived ChatStreamEventType = "membershipgiftreceived"
+)
+
 type CustomError struct {
 	message string
 }
@@ -24,6 +58,59 @@ func (e *CustomError) Error() string {
 	return e.message
 }
 
+var (
+	ErrStreamNotLive = &CustomError{message: "Channel is not live"}
+	ErrWaitStopped   = &CustomError{message: "Stopped waiting for live stream"}
+
+	errYoutubeVideoNotFound = &CustomError{message: "YouTube video not found"}
+)
+
+type ChatRecordType string
+
+const (
+	ChatRecordTypeMessage  ChatRecordType = "msg"
+	ChatRecordTypeEvent    ChatRecordType = "event"
+	ChatRecordTypeDeletion ChatRecordType = "deletion"
+)
+
+// ChatRecord é uma linha do arquivo JSON Lines de gravação do chat
+type ChatRecord struct {
+	RecordedAt   int64 // Unix em milissegundos
+	Type         ChatRecordType
+	Channel      string
+	ChannelLabel string
+	Message      *ChatStreamMessage  `json:",omitempty"`
+	Event        *ChatStreamEvent    `json:",omitempty"`
+	Deletion     *ChatStreamDeletion `json:",omitempty"`
+}
+
+type YoutubeInputType string
+
+const (
+	YoutubeInputTypeHandle    YoutubeInputType = "handle"
+	YoutubeInputTypeChannelId YoutubeInputType = "channelId"
+	YoutubeInputTypeVideoId   YoutubeInputType = "videoId"
+	YoutubeInputTypeCustomURL YoutubeInputType = "customUrl" // Links antigos /c/Nome e /user/Nome, Value guarda "c/Nome"
+)
+
+type YoutubeInput struct {
+	Type  YoutubeInputType
+	Value string
+}
+
+const (
+	YoutubeWaitMinInterval = 15 * time.Second
+	YoutubeWaitMaxInterval = 2 * time.Minute
+)
+
+type ChatStreamDeletionType string
+
+const (
+	ChatStreamDeletionTypeMessage ChatStreamDeletionType = "message"
+	ChatStreamDeletionTypeUser    ChatStreamDeletionType = "user"
+	ChatStreamDeletionTypeAll     ChatStreamDeletionType = "all"
+)
+
 const ChatStreamMessageBufferSize = 200
 
 type ChatStreamMessagePart struct {
@@ -35,10 +122,13 @@ type ChatStreamMessagePart struct {
 
 type ChatStreamMessage struct {
 	Platform     PlatformType
+	Id           string
+	UserId       string
 	Name         string
 	MessageParts []ChatStreamMessagePart
 	Timestamp    int64
 	Badges       []ChatUserBadge
+	Bits         int
 }
 
 func (m *ChatStreamMessage) GetMessagePlainText() string {
@@ -49,6 +139,42 @@ func (m *ChatStreamMessage) GetMessagePlainText() string {
 	return messageText
 }
 
+// ChatStreamEvent representa notificações do chat que não são mensagens comuns
+// (inscrições, presentes, raids, anúncios...). MessageParts pode estar vazio
+// quando o usuário não escreveu nenhuma mensagem junto do evento.
+type ChatStreamEvent struct {
+	Platform     PlatformType
+	EventType    ChatStreamEventType
+	UserId       string
+	Name         string
+	SystemText   string
+	MessageParts []ChatStreamMessagePart
+	Timestamp    int64
+	Badges       []ChatUserBadge
+	Months       int
+	Tier         string
+	Gifter       string
+	Recipient    string
+	GiftCount    int
+	ViewerCount  int
+	Color        string
+
+	Amount        string
+	Currency      string
+	HeaderColor   string
+	BodyColor     string
+	StickerImgUrl string
+}
+
+// ChatStreamDeletion pede que mensagens já exibidas sejam removidas do chat.
+// MessageId é usado em DeletionType "message" e UserId em "user".
+type ChatStreamDeletion struct {
+	Platform     PlatformType
+	DeletionType ChatStreamDeletionType
+	MessageId    string
+	UserId       string
+}
+
 type ChatUserBadge struct {
 	Name   string
 	ImgSrc string
@@ -58,17 +184,23 @@ type ChatUserBadge struct {
 type ChatStreamCon interface {
 	IsConnected() bool
 	GetMessagesChan() <-chan ChatStreamMessage
+	GetEventsChan() <-chan ChatStreamEvent
+	GetDeletionsChan() <-chan ChatStreamDeletion
 	Close()
 	GetPlatform() PlatformType
+	GetChannelId() string
 	GetUserId() string
 }
 
 type YTChatStreamCon struct {
 	ChannelID         string
 	UserID            string
+	VideoID           string
 	ContinuationToken string
 	LastStreamUpdate  int64
 	stream            chan ChatStreamMessage
+	events            chan ChatStreamEvent
+	deletions         chan ChatStreamDeletion
 }
 
 func (c *YTChatStreamCon) IsConnected() bool {
@@ -77,46 +209,248 @@ func (c *YTChatStreamCon) IsConnected() bool {
 func (c *YTChatStreamCon) GetMessagesChan() <-chan ChatStreamMessage {
 	return c.stream
 }
+func (c *YTChatStreamCon) GetEventsChan() <-chan ChatStreamEvent {
+	return c.events
+}
+func (c *YTChatStreamCon) GetDeletionsChan() <-chan ChatStreamDeletion {
+	return c.deletions
+}
 func (c *YTChatStreamCon) Close() {
 	if c.stream != nil {
 		close(c.stream)
 		c.stream = nil // Clear the stream to prevent further messages
 	}
+	if c.events != nil {
+		close(c.events)
+		c.events = nil
+	}
+	if c.deletions != nil {
+		close(c.deletions)
+		c.deletions = nil
+	}
 }
 func (c *YTChatStreamCon) GetPlatform() PlatformType {
 	return PlatformTypeYoutube
 }
+func (c *YTChatStreamCon) GetChannelId() string {
+	return c.ChannelID
+}
+func (c *YTChatStreamCon) GetVideoId() string {
+	return c.VideoID
+}
 func (c *YTChatStreamCon) GetUserId() string {
 	return c.UserID
 }
 
 type TWChatStreamCon struct {
-	ChannelID string
-	UserID    string
-	ws        *websocket.Conn
-	stream    chan ChatStreamMessage
-	badgesDB  map[string]ChatUserBadge
+	ChannelID    string
+	UserID       string
+	ws           *websocket.Conn
+	stream       chan ChatStreamMessage
+	events       chan ChatStreamEvent
+	deletions    chan ChatStreamDeletion
+	badgesDB     map[string]ChatUserBadge
+	cheermotesDB map[string]TWCheermote
+	done         chan struct{}
+	closeOnce    sync.Once
+}
+
+// TWCheermote descreve um prefixo de cheer (ex: "Cheer") e os tiers de bits disponíveis.
+// TemplateURL segue o formato da Twitch, com PREFIX, BACKGROUND, ANIMATION, TIER, SCALE e EXTENSION.
+type TWCheermote struct {
+	Prefix      string
+	Tiers       []int
+	TemplateURL string
 }
 
 func (c *TWChatStreamCon) IsConnected() bool {
-	return c.stream != nil && c.ws != nil
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
 }
 func (c *TWChatStreamCon) GetMessagesChan() <-chan ChatStreamMessage {
 	return c.stream
 }
+func (c *TWChatStreamCon) GetEventsChan() <-chan ChatStreamEvent {
+	return c.events
+}
+func (c *TWChatStreamCon) GetDeletionsChan() <-chan ChatStreamDeletion {
+	return c.deletions
+}
+
+// Close só sinaliza e derruba o socket, os canais são fechados pela goroutine de leitura quando ela para
 func (c *TWChatStreamCon) Close() {
-	if c.stream != nil {
-		close(c.stream)
-		c.stream = nil
-	}
-	if c.ws != nil {
-		c.ws.Close()
-		c.ws = nil
-	}
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.ws != nil {
+			c.ws.Close()
+		}
+	})
 }
 func (c *TWChatStreamCon) GetPlatform() PlatformType {
 	return PlatformTypeTwitch
 }
+func (c *TWChatStreamCon) GetChannelId() string {
+	return c.ChannelID
+}
 func (c *TWChatStreamCon) GetUserId() string {
 	return c.UserID
 }
+
+type KickSubscriberBadge struct {
+	Months int
+	ImgSrc string
+}
+
+type KickChatStreamCon struct {
+	ChannelID        string
+	UserID           string
+	ChatroomID       string
+	endpoints        KickEndpoints
+	ws               *websocket.Conn
+	stream           chan ChatStreamMessage
+	events           chan ChatStreamEvent
+	deletions        chan ChatStreamDeletion
+	badgesDB         map[string]ChatUserBadge
+	subscriberBadges []KickSubscriberBadge
+	done             chan struct{}
+	closeOnce        sync.Once
+}
+
+func (c *KickChatStreamCon) IsConnected() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+func (c *KickChatStreamCon) GetMessagesChan() <-chan ChatStreamMessage {
+	return c.stream
+}
+func (c *KickChatStreamCon) GetEventsChan() <-chan ChatStreamEvent {
+	return c.events
+}
+func (c *KickChatStreamCon) GetDeletionsChan() <-chan ChatStreamDeletion {
+	return c.deletions
+}
+
+// Close só sinaliza e derruba o socket, os canais são fechados pela goroutine de leitura quando ela para
+func (c *KickChatStreamCon) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.ws != nil {
+			c.ws.Close()
+		}
+	})
+}
+func (c *KickChatStreamCon) GetPlatform() PlatformType {
+	return PlatformTypeKick
+}
+func (c *KickChatStreamCon) GetChannelId() string {
+	return c.ChannelID
+}
+func (c *KickChatStreamCon) GetUserId() string {
+	return c.UserID
+}
+
+// ReplayChatStreamCon reproduz um dos canais de uma gravação, com o id e o rótulo gravados
+type ReplayChatStreamCon struct {
+	FilePath  string
+	Speed     float64
+	Channel   string
+	Label     string
+	stream    chan ChatStreamMessage
+	events    chan ChatStreamEvent
+	deletions chan ChatStreamDeletion
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *ReplayChatStreamCon) IsConnected() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+func (c *ReplayChatStreamCon) GetMessagesChan() <-chan ChatStreamMessage {
+	return c.stream
+}
+func (c *ReplayChatStreamCon) GetEventsChan() <-chan ChatStreamEvent {
+	return c.events
+}
+func (c *ReplayChatStreamCon) GetDeletionsChan() <-chan ChatStreamDeletion {
+	return c.deletions
+}
+
+// Close só sinaliza, os canais são fechados pela goroutine que lê o arquivo quando ela para
+func (c *ReplayChatStreamCon) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+func (c *ReplayChatStreamCon) GetPlatform() PlatformType {
+	return PlatformTypeReplay
+}
+func (c *ReplayChatStreamCon) GetChannelId() string {
+	return c.Channel
+}
+func (c *ReplayChatStreamCon) GetUserId() string {
+	return ""
+}
+
+type SimulatorChatStreamCon struct {
+	config         SimulatorConfig
+	intervalMillis atomic.Int64
+	stream         chan ChatStreamMessage
+	events         chan ChatStreamEvent
+	deletions      chan ChatStreamDeletion
+	done           chan struct{}
+	closeOnce      sync.Once
+}
+
+// SetMessagesPerSecond altera o ritmo do chat simulado sem precisar reconectar
+func (c *SimulatorChatStreamCon) SetMessagesPerSecond(messagesPerSecond float64) {
+	c.intervalMillis.Store(int64(1000 / clampSimulatorRate(messagesPerSecond)))
+}
+func (c *SimulatorChatStreamCon) getInterval() time.Duration {
+	return time.Duration(c.intervalMillis.Load()) * time.Millisecond
+}
+func (c *SimulatorChatStreamCon) IsConnected() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+func (c *SimulatorChatStreamCon) GetMessagesChan() <-chan ChatStreamMessage {
+	return c.stream
+}
+func (c *SimulatorChatStreamCon) GetEventsChan() <-chan ChatStreamEvent {
+	return c.events
+}
+func (c *SimulatorChatStreamCon) GetDeletionsChan() <-chan ChatStreamDeletion {
+	return c.deletions
+}
+
+// Close só sinaliza, os canais são fechados pela goroutine geradora quando ela para
+func (c *SimulatorChatStreamCon) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+func (c *SimulatorChatStreamCon) GetPlatform() PlatformType {
+	return PlatformTypeSimulator
+}
+func (c *SimulatorChatStreamCon) GetChannelId() string {
+	return "simulator"
+}
+func (c *SimulatorChatStreamCon) GetUserId() string {
+	return ""
+}
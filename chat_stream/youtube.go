@@ -2,19 +2,89 @@ package chat_stream
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+var (
+	ytChannelIdRegex = regexp.MustCompile(`^UC[A-Za-z0-9_-]{22}$`)
+	ytVideoIdRegex   = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+)
+
+// ParseYoutubeInput identifica o que foi digitado no campo do YouTube: @handle, ID de
+// canal (UC...), ID de vídeo ou URLs watch?v=, youtu.be, /live/, /channel/, /c/, /user/ e /@handle
+func ParseYoutubeInput(input string) YoutubeInput {
+	input = strings.TrimSpace(input)
+	if strings.Contains(input, "/") {
+		return parseYoutubeURL(input)
+	}
+	if strings.HasPrefix(input, "@") {
+		return YoutubeInput{Type: YoutubeInputTypeHandle, Value: strings.TrimPrefix(input, "@")}
+	}
+	if ytChannelIdRegex.MatchString(input) {
+		return YoutubeInput{Type: YoutubeInputTypeChannelId, Value: input}
+	}
+	if ytVideoIdRegex.MatchString(input) {
+		return YoutubeInput{Type: YoutubeInputTypeVideoId, Value: input}
+	}
+	return YoutubeInput{Type: YoutubeInputTypeHandle, Value: input}
+}
+
+// NormalizeYoutubeInput reduz qualquer forma aceita ao identificador salvo no estado
+func NormalizeYoutubeInput(input string) string {
+	parsed := ParseYoutubeInput(input)
+	if parsed.Type == YoutubeInputTypeHandle && ytVideoIdRegex.MatchString(parsed.Value) {
+		return "@" + parsed.Value
+	}
+	if parsed.Type == YoutubeInputTypeCustomURL {
+		// Continua como link, senão "c/Nome" seria lido de volta como outro canal
+		return "youtube.com/" + parsed.Value
+	}
+	return parsed.Value
+}
+
+func parseYoutubeURL(input string) YoutubeInput {
+	if !strings.Contains(input, "://") {
+		input = "https://" + input
+	}
+	parsedUrl, err := url.Parse(input)
+	if err != nil {
+		return YoutubeInput{Type: YoutubeInputTypeHandle, Value: input}
+	}
+	segments := strings.Split(strings.Trim(parsedUrl.Path, "/"), "/")
+	host := strings.TrimPrefix(parsedUrl.Hostname(), "www.")
+
+	if host == "youtu.be" && segments[0] != "" {
+		return YoutubeInput{Type: YoutubeInputTypeVideoId, Value: segments[0]}
+	}
+	if videoId := parsedUrl.Query().Get("v"); segments[0] == "watch" && videoId != "" {
+		return YoutubeInput{Type: YoutubeInputTypeVideoId, Value: videoId}
+	}
+	if len(segments) >= 2 && segments[0] == "live" {
+		return YoutubeInput{Type: YoutubeInputTypeVideoId, Value: segments[1]}
+	}
+	if len(segments) >= 2 && segments[0] == "channel" {
+		return YoutubeInput{Type: YoutubeInputTypeChannelId, Value: segments[1]}
+	}
+	if len(segments) >= 2 && (segments[0] == "c" || segments[0] == "user") {
+		return YoutubeInput{Type: YoutubeInputTypeCustomURL, Value: segments[0] + "/" + segments[1]}
+	}
+	return YoutubeInput{Type: YoutubeInputTypeHandle, Value: strings.TrimPrefix(segments[0], "@")}
+}
+
 func ConnectToYoutubeChat(channelID string) (ChatStreamCon, error) {
-	streamUrl, err := getLiveStreamFromChannelId(channelID)
+	videoId, err := getLiveVideoIdFromInput(ParseYoutubeInput(channelID))
 	if err != nil {
 		return nil, err
 	}
+	streamUrl := "https://www.youtube.com/watch?v=" + videoId
 	log.Println("Live stream URL for channel", channelID, "is", streamUrl)
 	continuationToken, err := getContinuationFromURL(streamUrl)
 	if err != nil {
@@ -29,15 +99,18 @@ func ConnectToYoutubeChat(channelID string) (ChatStreamCon, error) {
 		return nil, err
 	}
 
-	return generateChatStream(channelID, userId, continuationToken)
+	return generateChatStream(channelID, userId, videoId, continuationToken)
 }
 
-func generateChatStream(channelID string, userId string, continuationToken string) (ChatStreamCon, error) {
+func generateChatStream(channelID string, userId string, videoId string, continuationToken string) (ChatStreamCon, error) {
 	log.Println("Starting YouTube chat stream for channel:", channelID, "with user ID:", userId)
 	con := &YTChatStreamCon{
 		ChannelID:         channelID,
 		UserID:            userId,
+		VideoID:           videoId,
 		stream:            make(chan ChatStreamMessage, ChatStreamMessageBufferSize),
+		events:            make(chan ChatStreamEvent, ChatStreamMessageBufferSize),
+		deletions:         make(chan ChatStreamDeletion, ChatStreamMessageBufferSize),
 		ContinuationToken: continuationToken,
 		LastStreamUpdate:  0,
 	}
@@ -54,14 +127,20 @@ func generateChatStream(channelID string, userId string, continuationToken strin
 	return con, nil
 }
 
+type ytChatUpdates struct {
+	messages  []ChatStreamMessage
+	events    []ChatStreamEvent
+	deletions []ChatStreamDeletion
+}
+
 func iterateAndStreamNewMessages(con *YTChatStreamCon) {
-	newMessages, err := iterateOnMessages(con)
+	updates, err := iterateOnMessages(con)
 	if err != nil {
 		log.Println("Error iterating on messages:", err)
 		con.Close()
 		return
 	}
-	for _, msg := range newMessages {
+	for _, msg := range updates.messages {
 		if !con.IsConnected() {
 			log.Println("Chat stream connection closed, stopping message processing")
 			return
@@ -72,9 +151,31 @@ func iterateAndStreamNewMessages(con *YTChatStreamCon) {
 			log.Println("Chat stream buffer is full, dropping message:", msg)
 		}
 	}
+	for _, event := range updates.events {
+		if !con.IsConnected() {
+			log.Println("Chat stream connection closed, stopping event processing")
+			return
+		}
+		select {
+		case con.events <- event:
+		default:
+			log.Println("Chat stream buffer is full, dropping event:", event)
+		}
+	}
+	for _, deletion := range updates.deletions {
+		if !con.IsConnected() {
+			log.Println("Chat stream connection closed, stopping deletion processing")
+			return
+		}
+		select {
+		case con.deletions <- deletion:
+		default:
+			log.Println("Chat stream buffer is full, dropping deletion:", deletion)
+		}
+	}
 }
 
-func iterateOnMessages(con *YTChatStreamCon) ([]ChatStreamMessage, error) {
+func iterateOnMessages(con *YTChatStreamCon) (*ytChatUpdates, error) {
 	response, err := getMessagesAPIResponse(con.ContinuationToken)
 	if err != nil {
 		return nil, err
@@ -86,14 +187,18 @@ func iterateOnMessages(con *YTChatStreamCon) ([]ChatStreamMessage, error) {
 	}
 	con.ContinuationToken = newContinuationToken
 
-	messages := make([]ChatStreamMessage, 0)
+	updates := &ytChatUpdates{
+		messages:  make([]ChatStreamMessage, 0),
+		events:    make([]ChatStreamEvent, 0),
+		deletions: make([]ChatStreamDeletion, 0),
+	}
 	messagesData, ok := GetDeepMapValue(response, []any{
 		"continuationContents",
 		"liveChatContinuation",
 		"actions",
 	}, false)
 	if !ok {
-		return messages, nil
+		return updates, nil
 	}
 	actions, ok := messagesData.([]any)
 	if !ok {
@@ -101,43 +206,305 @@ func iterateOnMessages(con *YTChatStreamCon) ([]ChatStreamMessage, error) {
 	}
 
 	for _, action := range actions {
-		item, ok := GetDeepMapValue(action.(map[string]any), []any{
+		actionMap, ok := action.(map[string]any)
+		if !ok {
+			continue
+		}
+		if deletion := getDeletionFromAction(actionMap); deletion != nil {
+			updates.deletions = append(updates.deletions, *deletion)
+			continue
+		}
+		itemData, ok := GetDeepMapValue(actionMap, []any{
 			"addChatItemAction",
 			"item",
-			"liveChatTextMessageRenderer",
 		}, true)
 		if !ok {
 			continue
 		}
-		message, err := getMessageFromChatItem(item.(map[string]any), con.LastStreamUpdate)
+		item, ok := itemData.(map[string]any)
+		if !ok {
+			continue
+		}
+		if textItem, ok := item["liveChatTextMessageRenderer"].(map[string]any); ok {
+			message, err := getMessageFromChatItem(textItem, con.LastStreamUpdate)
+			if err != nil {
+				log.Println("Error getting message from chat item:", err)
+				continue
+			}
+			if message == nil {
+				continue
+			}
+			updates.messages = append(updates.messages, *message)
+			continue
+		}
+		event, err := getEventFromChatItem(item, con.LastStreamUpdate)
 		if err != nil {
-			log.Println("Error getting message from chat item:", err)
+			log.Println("Error getting event from chat item:", err)
 			continue
 		}
-		if message == nil {
+		if event == nil {
 			continue
 		}
-		messages = append(messages, *message)
+		updates.events = append(updates.events, *event)
+	}
+	for _, message := range updates.messages {
+		con.LastStreamUpdate = max(con.LastStreamUpdate, message.Timestamp)
 	}
-	if len(messages) > 0 {
-		con.LastStreamUpdate = messages[len(messages)-1].Timestamp
+	for _, event := range updates.events {
+		con.LastStreamUpdate = max(con.LastStreamUpdate, event.Timestamp)
 	}
 
-	return messages, nil
+	return updates, nil
 }
 
-func getMessageFromChatItem(item map[string]any, lastTimeUpdate int64) (*ChatStreamMessage, error) {
+// Renderers do YouTube que viram eventos, na ordem em que são procurados no item
+var ytEventRenderers = []struct {
+	key       string
+	eventType ChatStreamEventType
+}{
+	{"liveChatPaidMessageRenderer", ChatStreamEventTypeSuperChat},
+	{"liveChatPaidStickerRenderer", ChatStreamEventTypeSuperSticker},
+	{"liveChatMembershipItemRenderer", ChatStreamEventTypeMembership},
+	{"liveChatSponsorshipsGiftPurchaseAnnouncementRenderer", ChatStreamEventTypeMembershipGift},
+	{"liveChatSponsorshipsGiftRedemptionAnnouncementRenderer", ChatStreamEventTypeMembershipGiftReceived},
+}
+
+// getEventRenderer devolve o primeiro renderer de evento do item, nil quando o item não é um evento
+func getEventRenderer(item map[string]any) (ChatStreamEventType, map[string]any) {
+	for _, candidate := range ytEventRenderers {
+		if renderer, ok := item[candidate.key].(map[string]any); ok {
+			return candidate.eventType, renderer
+		}
+	}
+	return "", nil
+}
+
+func getEventFromChatItem(item map[string]any, lastTimeUpdate int64) (*ChatStreamEvent, error) {
+	eventType, renderer := getEventRenderer(item)
+	if renderer == nil {
+		return nil, nil // Item não suportado
+	}
+
+	timestampInt, err := getTimestampFromChatItem(renderer)
+	if err != nil {
+		return nil, err
+	}
+	if timestampInt <= lastTimeUpdate {
+		return nil, nil
+	}
+
+	event := &ChatStreamEvent{
+		Platform:     PlatformTypeYoutube,
+		EventType:    eventType,
+		Timestamp:    timestampInt,
+		MessageParts: []ChatStreamMessagePart{},
+	}
+
+	// O anúncio de presentes guarda os dados do autor dentro de um header
+	author := renderer
+	if rawHeader, ok := GetDeepMapValue(renderer, []any{"header", "liveChatSponsorshipsHeaderRenderer"}, true); ok {
+		header, ok := rawHeader.(map[string]any)
+		if !ok {
+			return nil, &CustomError{message: "Event header is not in expected format"}
+		}
+		author = header
+	}
+	if rawName, ok := GetDeepMapValue(author, []any{"authorName", "simpleText"}, true); ok {
+		name, ok := rawName.(string)
+		if !ok {
+			return nil, &CustomError{message: "Event author name is not in expected format"}
+		}
+		event.Name = name
+	}
+	event.Badges = getBadgesFromChatItem(author)
+	if rawRuns, ok := GetDeepMapValue(renderer, []any{"message", "runs"}, true); ok {
+		runs, ok := rawRuns.([]any)
+		if !ok {
+			return nil, &CustomError{message: "Event message is not in expected format"}
+		}
+		event.MessageParts = getMessagePartsFromRuns(runs)
+	}
+
+	switch eventType {
+	case ChatStreamEventTypeSuperChat, ChatStreamEventTypeSuperSticker:
+		if rawAmountText, ok := GetDeepMapValue(renderer, []any{"purchaseAmountText", "simpleText"}, true); ok {
+			amountText, ok := rawAmountText.(string)
+			if !ok {
+				return nil, &CustomError{message: "Event purchase amount is not in expected format"}
+			}
+			event.SystemText = amountText
+			event.Currency, event.Amount = splitYtPurchaseAmount(amountText)
+		}
+		if eventType == ChatStreamEventTypeSuperChat {
+			event.HeaderColor = ytColorToCSS(renderer["headerBackgroundColor"])
+			event.BodyColor = ytColorToCSS(renderer["bodyBackgroundColor"])
+		} else {
+			event.HeaderColor = ytColorToCSS(renderer["moneyChipBackgroundColor"])
+			event.BodyColor = ytColorToCSS(renderer["backgroundColor"])
+			if rawStickerUrl, ok := GetDeepMapValue(renderer, []any{"sticker", "thumbnails", -1, "url"}, true); ok {
+				stickerUrl, ok := rawStickerUrl.(string)
+				if !ok {
+					return nil, &CustomError{message: "Event sticker is not in expected format"}
+				}
+				event.StickerImgUrl = stickerUrl
+				if strings.HasPrefix(event.StickerImgUrl, "//") {
+					event.StickerImgUrl = "https:" + event.StickerImgUrl
+				}
+			}
+		}
+	case ChatStreamEventTypeMembership:
+		primaryText := getYtText(renderer["headerPrimaryText"])
+		subText := getYtText(renderer["headerSubtext"])
+		if primaryText != "" {
+			// Marco de meses como membro, ex: "Membro há 5 meses"
+			event.SystemText = primaryText
+			event.Months = getFirstNumber(primaryText)
+			event.Tier = subText
+		} else {
+			event.SystemText = subText
+		}
+	case ChatStreamEventTypeMembershipGift:
+		event.SystemText = getYtText(author["primaryText"])
+		event.GiftCount = getFirstNumber(event.SystemText)
+		event.Gifter = event.Name
+	case ChatStreamEventTypeMembershipGiftReceived:
+		event.SystemText = getYtText(renderer["message"])
+		event.Recipient = event.Name
+		if rawGifter, ok := GetDeepMapValue(renderer, []any{"message", "runs", -1, "text"}, true); ok {
+			gifter, ok := rawGifter.(string)
+			if !ok {
+				return nil, &CustomError{message: "Event gifter is not in expected format"}
+			}
+			event.Gifter = gifter
+		}
+		// A mensagem deste item é o texto do sistema, não algo escrito pelo usuário
+		event.MessageParts = []ChatStreamMessagePart{}
+	}
+
+	return event, nil
+}
+
+func getTimestampFromChatItem(item map[string]any) (int64, error) {
 	timestamp, ok := GetDeepMapValue(item, []any{
 		"timestampUsec",
 	}, false)
 	if !ok {
-		return nil, &CustomError{message: "Timestamp not found in chat item"}
+		return 0, &CustomError{message: "Timestamp not found in chat item"}
+	}
+	timestampText, ok := timestamp.(string)
+	if !ok {
+		return 0, &CustomError{message: "Timestamp is not in expected format"}
 	}
-	timestampInt, err := strconv.ParseInt(timestamp.(string), 10, 64)
+	timestampInt, err := strconv.ParseInt(timestampText, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return timestampInt / 1000, nil
+}
+
+// getYtText junta o texto de um campo que pode vir como simpleText ou como lista de runs
+func getYtText(data any) string {
+	textData, ok := data.(map[string]any)
+	if !ok {
+		return ""
+	}
+	if simpleText, ok := textData["simpleText"].(string); ok {
+		return simpleText
+	}
+	runs, ok := textData["runs"].([]any)
+	if !ok {
+		return ""
+	}
+	var text string
+	for _, run := range runs {
+		runMap, ok := run.(map[string]any)
+		if !ok {
+			continue
+		}
+		if runText, ok := runMap["text"].(string); ok {
+			text += runText
+		}
+	}
+	return text
+}
+
+func getFirstNumber(text string) int {
+	digits := ""
+	for _, char := range text {
+		if char >= '0' && char <= '9' {
+			digits += string(char)
+		} else if digits != "" {
+			break
+		}
+	}
+	number, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// splitYtPurchaseAmount separa a moeda do valor, ex: "R$ 10,00" -> ("R$", "10,00")
+func splitYtPurchaseAmount(amountText string) (string, string) {
+	index := strings.IndexFunc(amountText, func(char rune) bool {
+		return char >= '0' && char <= '9'
+	})
+	if index < 0 {
+		return "", amountText
+	}
+	currency := strings.TrimSpace(strings.ReplaceAll(amountText[:index], "\u00a0", " "))
+	return currency, strings.TrimSpace(amountText[index:])
+}
+
+// ytColorToCSS converte as cores ARGB numéricas do YouTube para o formato #RRGGBBAA
+func ytColorToCSS(value any) string {
+	color, ok := value.(float64)
+	if !ok {
+		return ""
+	}
+	argb := uint32(color)
+	return fmt.Sprintf("#%06x%02x", argb&0xFFFFFF, argb>>24)
+}
+
+func getDeletionFromAction(action map[string]any) *ChatStreamDeletion {
+	for _, key := range []string{"markChatItemAsDeletedAction", "removeChatItemAction"} {
+		rawTargetId, ok := GetDeepMapValue(action, []any{key, "targetItemId"}, true)
+		if !ok {
+			continue
+		}
+		targetId, ok := rawTargetId.(string)
+		if !ok || targetId == "" {
+			return nil
+		}
+		return &ChatStreamDeletion{
+			Platform:     PlatformTypeYoutube,
+			DeletionType: ChatStreamDeletionTypeMessage,
+			MessageId:    targetId,
+		}
+	}
+	for _, key := range []string{"markChatItemsByAuthorAsDeletedAction", "removeChatItemByAuthorAction"} {
+		rawAuthorId, ok := GetDeepMapValue(action, []any{key, "externalChannelId"}, true)
+		if !ok {
+			continue
+		}
+		authorId, ok := rawAuthorId.(string)
+		if !ok || authorId == "" {
+			return nil
+		}
+		return &ChatStreamDeletion{
+			Platform:     PlatformTypeYoutube,
+			DeletionType: ChatStreamDeletionTypeUser,
+			UserId:       authorId,
+		}
+	}
+	return nil
+}
+
+func getMessageFromChatItem(item map[string]any, lastTimeUpdate int64) (*ChatStreamMessage, error) {
+	timestampInt, err := getTimestampFromChatItem(item)
 	if err != nil {
 		return nil, err
 	}
-	timestampInt = timestampInt / 1000
 
 	if timestampInt <= lastTimeUpdate {
 		return nil, nil // Skip messages that are older than the last update
@@ -158,22 +525,20 @@ func getMessageFromChatItem(item map[string]any, lastTimeUpdate int64) (*ChatStr
 	if !ok {
 		return nil, &CustomError{message: "Message text not found in chat item"}
 	}
-	var messageParts []ChatStreamMessagePart
-	for _, messageEntry := range messagesText.([]any) {
-		message, err := getMessagePartFromChatItemEntry(messageEntry.(map[string]any))
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-		messageParts = append(messageParts, message)
-	}
-
+	messageParts := getMessagePartsFromRuns(messagesText.([]any))
 	if len(messageParts) == 0 {
 		return nil, &CustomError{message: "No valid message parts found in chat item"}
 	}
 
+	messageId, _ := GetDeepMapValue(item, []any{"id"}, true)
+	authorId, _ := GetDeepMapValue(item, []any{"authorExternalChannelId"}, true)
+	messageIdStr, _ := messageId.(string)
+	authorIdStr, _ := authorId.(string)
+
 	return &ChatStreamMessage{
 		Platform:     PlatformTypeYoutube,
+		Id:           messageIdStr,
+		UserId:       authorIdStr,
 		Name:         name.(string),
 		MessageParts: messageParts,
 		Timestamp:    timestampInt,
@@ -181,6 +546,23 @@ func getMessageFromChatItem(item map[string]any, lastTimeUpdate int64) (*ChatStr
 	}, nil
 }
 
+func getMessagePartsFromRuns(runs []any) []ChatStreamMessagePart {
+	var messageParts []ChatStreamMessagePart
+	for _, messageEntry := range runs {
+		entry, ok := messageEntry.(map[string]any)
+		if !ok {
+			continue
+		}
+		message, err := getMessagePartFromChatItemEntry(entry)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		messageParts = append(messageParts, message)
+	}
+	return messageParts
+}
+
 func getBadgesFromChatItem(item map[string]any) []ChatUserBadge {
 	badgesData, ok := GetDeepMapValue(item, []any{
 		"authorBadges",
@@ -365,7 +747,7 @@ func getContinuationFromAPIResponse(response map[string]any) (string, error) {
 }
 
 func getContinuationFromURL(streamUrl string) (string, error) {
-	resp, err := http.Get(streamUrl)
+	resp, err := youtubePageClient.Get(streamUrl)
 	if err != nil {
 		return "", err
 	}
@@ -391,7 +773,7 @@ func getContinuationFromURL(streamUrl string) (string, error) {
 }
 
 func getUserIdFromURL(streamUrl string) (string, error) {
-	resp, err := http.Get(streamUrl)
+	resp, err := youtubePageClient.Get(streamUrl)
 	if err != nil {
 		return "", err
 	}
@@ -425,13 +807,48 @@ func getContinuationFromAllChat(continuationToken string) (string, error) {
 	return getContinuationFromAPIResponse(parsed)
 }
 
-func getLiveStreamFromChannelId(channelID string) (string, error) {
-	resp, err := getYoutubeInitialData(channelID)
+func getLiveVideoIdFromInput(input YoutubeInput) (string, error) {
+	switch input.Type {
+	case YoutubeInputTypeVideoId:
+		videoId, err := getLiveVideoIdFromVideo(input.Value)
+		if err == errYoutubeVideoNotFound {
+			// Um handle de 11 caracteres é indistinguível de um ID de vídeo
+			return getLiveVideoIdFromChannelPage("https://www.youtube.com/@" + input.Value)
+		}
+		return videoId, err
+	case YoutubeInputTypeChannelId:
+		return getLiveVideoIdFromChannelPage("https://www.youtube.com/channel/" + input.Value)
+	case YoutubeInputTypeCustomURL:
+		return getLiveVideoIdFromChannelPage("https://www.youtube.com/" + input.Value)
+	default:
+		return getLiveVideoIdFromChannelPage("https://www.youtube.com/@" + input.Value)
+	}
+}
+
+func getLiveVideoIdFromVideo(videoId string) (string, error) {
+	body, err := getYoutubePage("https://www.youtube.com/watch?v=" + videoId)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(body, "\"videoDetails\":{\"videoId\":\""+videoId+"\"") {
+		return "", errYoutubeVideoNotFound
+	}
+	if strings.Contains(body, "\"isLiveNow\":true") {
+		return videoId, nil
+	}
+	if strings.Contains(body, "\"isUpcoming\":true") {
+		return "", ErrStreamNotLive
+	}
+	return "", &CustomError{message: "Video " + videoId + " is not a live stream"}
+}
+
+func getLiveVideoIdFromChannelPage(channelUrl string) (string, error) {
+	resp, err := getYoutubeInitialData(channelUrl)
 	if err != nil {
 		return "", err
 	}
 
-	videoId, ok := GetDeepMapValue(resp, []any{
+	videoRendererData, ok := GetDeepMapValue(resp, []any{
 		"contents",
 		"twoColumnBrowseResultsRenderer",
 		"tabs",
@@ -448,17 +865,87 @@ func getLiveStreamFromChannelId(channelID string) (string, error) {
 		"items",
 		0,
 		"videoRenderer",
-		"videoId",
 	}, false)
-	if !ok {
-		return "", &CustomError{message: "Live stream video ID not found in channel data"}
+	if videoRenderer, isMap := videoRendererData.(map[string]any); ok && isMap {
+		videoId, hasId := videoRenderer["videoId"].(string)
+		_, isUpcoming := videoRenderer["upcomingEventData"]
+		if hasId && !isUpcoming {
+			return videoId, nil
+		}
+	}
+
+	// O destaque do canal nem sempre aparece, a página /live aponta para a live atual ou a próxima agendada
+	return getLiveVideoIdFromLivePage(channelUrl)
+}
+
+func getLiveVideoIdFromLivePage(channelUrl string) (string, error) {
+	body, err := getYoutubePage(channelUrl + "/live")
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.Contains(body, "\"isLiveNow\":true") {
+		return "", ErrStreamNotLive
 	}
+	prefix := "<link rel=\"canonical\" href=\"https://www.youtube.com/watch?v="
+	index := strings.Index(body, prefix)
+	if index < 0 {
+		return "", ErrStreamNotLive
+	}
+	index += len(prefix)
+	endIndex := strings.Index(body[index:], "\"")
+	if endIndex < 0 {
+		return "", &CustomError{message: "End of canonical live URL not found"}
+	}
+
+	return body[index : index+endIndex], nil
+}
+
+// youtubePageClient baixa as páginas do YouTube, sem timeout uma requisição travada prende quem conecta o canal
+var youtubePageClient = http.Client{
+	Timeout: 10 * time.Second,
+}
+
+func getYoutubePage(pageUrl string) (string, error) {
+	resp, err := youtubePageClient.Get(pageUrl)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", &CustomError{message: "Failed to fetch " + pageUrl + ", status code: " + strconv.Itoa(resp.StatusCode)}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
 
-	return "https://www.youtube.com/watch?v=" + videoId.(string), nil
+// WaitForYoutubeLive consulta o canal periodicamente até a live começar, aumentando o
+// intervalo entre tentativas até YoutubeWaitMaxInterval. Retorna ErrWaitStopped se stop for fechado.
+func WaitForYoutubeLive(channelID string, stop <-chan struct{}) (ChatStreamCon, error) {
+	interval := YoutubeWaitMinInterval
+	for {
+		select {
+		case <-stop:
+			return nil, ErrWaitStopped
+		case <-time.After(interval):
+		}
+
+		con, err := ConnectToYoutubeChat(channelID)
+		if err == nil {
+			return con, nil
+		}
+		if err != ErrStreamNotLive {
+			log.Println("Error while waiting for YouTube live on channel", channelID, err)
+		}
+		interval = min(interval*2, YoutubeWaitMaxInterval)
+	}
 }
 
-func getYoutubeInitialData(channelID string) (map[string]any, error) {
-	resp, err := http.Get("https://www.youtube.com/@" + channelID)
+func getYoutubeInitialData(channelUrl string) (map[string]any, error) {
+	resp, err := youtubePageClient.Get(channelUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,36 @@
+package chat_stream
+
+import "testing"
+
+func TestParseYoutubeInput(t *testing.T) {
+	cases := []struct {
+		input string
+		want  YoutubeInput
+	}{
+		{"@Canal", YoutubeInput{Type: YoutubeInputTypeHandle, Value: "Canal"}},
+		{"Canal", YoutubeInput{Type: YoutubeInputTypeHandle, Value: "Canal"}},
+		{"UCabcdefghijklmnopqrstuv", YoutubeInput{Type: YoutubeInputTypeChannelId, Value: "UCabcdefghijklmnopqrstuv"}},
+		{"dQw4w9WgXcQ", YoutubeInput{Type: YoutubeInputTypeVideoId, Value: "dQw4w9WgXcQ"}},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", YoutubeInput{Type: YoutubeInputTypeVideoId, Value: "dQw4w9WgXcQ"}},
+		{"https://youtu.be/dQw4w9WgXcQ", YoutubeInput{Type: YoutubeInputTypeVideoId, Value: "dQw4w9WgXcQ"}},
+		{"youtube.com/live/dQw4w9WgXcQ", YoutubeInput{Type: YoutubeInputTypeVideoId, Value: "dQw4w9WgXcQ"}},
+		{"https://www.youtube.com/channel/UCabcdefghijklmnopqrstuv", YoutubeInput{Type: YoutubeInputTypeChannelId, Value: "UCabcdefghijklmnopqrstuv"}},
+		{"https://www.youtube.com/@Canal/streams", YoutubeInput{Type: YoutubeInputTypeHandle, Value: "Canal"}},
+		{"https://www.youtube.com/c/Canal", YoutubeInput{Type: YoutubeInputTypeCustomURL, Value: "c/Canal"}},
+		{"youtube.com/user/Canal/videos", YoutubeInput{Type: YoutubeInputTypeCustomURL, Value: "user/Canal"}},
+	}
+	for _, c := range cases {
+		if got := ParseYoutubeInput(c.input); got != c.want {
+			t.Errorf("ParseYoutubeInput(%q) = %+v, want %+v", c.input, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeYoutubeInputRoundTrip(t *testing.T) {
+	for _, input := range []string{"https://www.youtube.com/c/Canal", "youtube.com/user/Canal", "https://www.youtube.com/@Canal"} {
+		normalized := NormalizeYoutubeInput(input)
+		if again := ParseYoutubeInput(normalized); again != ParseYoutubeInput(input) {
+			t.Errorf("%q normalized to %q, which parses as %+v", input, normalized, again)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"overtube/chat_stream"
+	"overtube/control_api"
+	"overtube/ui"
+	"overtube/web_server"
+)
+
+// controlAdapter liga a API de controle ao mesmo fluxo da janela: cada chamada vira o ui.UIEvent
+// que o botão correspondente emitiria, e a janela recebe um comando para mostrar a mudança.
+// Depois que done fecha o app está encerrando e as chamadas são descartadas
+type controlAdapter struct {
+	uiEvents chan<- ui.UIEvent
+	done     <-chan struct{}
+	statuses *channelStatusTracker
+}
+
+func newControlAdapter(uiEvents chan<- ui.UIEvent, done <-chan struct{}, statuses *channelStatusTracker) *controlAdapter {
+	return &controlAdapter{uiEvents: uiEvents, done: done, statuses: statuses}
+}
+
+func (c *controlAdapter) emit(command ui.UICommand, event ui.UIEvent) {
+	select {
+	case uiCommandsChan <- command:
+	case <-c.done:
+		return
+	}
+	select {
+	case c.uiEvents <- event:
+	case <-c.done:
+	}
+}
+
+func (c *controlAdapter) AddChannel(platform chat_stream.PlatformType, channel string, label string) {
+	c.emit(
+		ui.ChannelAdded{Platform: platform, Channel: channel, Label: label},
+		ui.UIEventAddChannel{Platform: platform, Channel: channel, Label: label},
+	)
+}
+
+func (c *controlAdapter) RemoveChannel(platform chat_stream.PlatformType, channel string) {
+	c.emit(
+		ui.ChannelRemoved{Platform: platform, Channel: channel},
+		ui.UIEventRemoveChannel{Platform: platform, Channel: channel},
+	)
+}
+
+func (c *controlAdapter) SetChatStyle(id uint) {
+	c.emit(ui.ChatStyleChange{Id: id}, ui.UIEventSetChatStyle{Id: id})
+}
+
+func (c *controlAdapter) SetChatStyleCustomCSS(id uint, css string) {
+	c.emit(ui.ChatStyleCSSChange{Id: id, CSS: css}, ui.SetChatStyleCustomCSS{Id: id, CSS: css})
+}
+
+func (c *controlAdapter) ResetChatStyleCustomCSS(id uint) {
+	c.emit(ui.ChatStyleCSSChange{Id: id, CSS: web_server.GetChatStyleFromId(id).CSS}, ui.ResetChatStyleCustomCSS{Id: id})
+}
+
+func (c *controlAdapter) GetChannels() []control_api.ChannelStatus {
+	return c.statuses.list()
+}
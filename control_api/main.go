@@ -0,0 +1,152 @@
+package control_api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"overtube/chat_stream"
+	"overtube/web_server"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NewHandler monta as rotas em /api/, quem chama deve proteger com o token de controle
+func NewHandler(controller Controller) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", func(w http.ResponseWriter, r *http.Request) {
+		channels := controller.GetChannels()
+		if channels == nil {
+			channels = []ChannelStatus{}
+		}
+		writeJSON(w, http.StatusOK, StatusResponse{Channels: channels})
+	})
+	mux.HandleFunc("POST /api/channels", func(w http.ResponseWriter, r *http.Request) {
+		var request AddChannelRequest
+		if !readJSON(w, r, &request) {
+			return
+		}
+		channel, ok := normalizeChannel(request.Platform, request.Channel)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid platform or channel")
+			return
+		}
+		label := strings.TrimSpace(request.Label)
+		if utf8.RuneCountInString(label) > MAX_LABEL_LENGTH {
+			writeError(w, http.StatusBadRequest, "label too long")
+			return
+		}
+		controller.AddChannel(request.Platform, channel, label)
+		// A conexão continua em segundo plano, o andamento aparece em /api/status
+		writeJSON(w, http.StatusAccepted, ChannelStatus{Platform: request.Platform, Channel: channel, Label: label, Status: "starting"})
+	})
+	mux.HandleFunc("DELETE /api/channels/{platform}/{channel}", func(w http.ResponseWriter, r *http.Request) {
+		platform := chat_stream.PlatformType(r.PathValue("platform"))
+		channel := r.PathValue("channel")
+		if !hasChannel(controller, platform, channel) {
+			writeError(w, http.StatusNotFound, "channel not found")
+			return
+		}
+		controller.RemoveChannel(platform, channel)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /api/styles", func(w http.ResponseWriter, r *http.Request) {
+		styles := []ChatStyle{}
+		for _, style := range web_server.GetChatStyleOptions() {
+			styles = append(styles, ChatStyle{Id: style.Id, Label: style.Label})
+		}
+		writeJSON(w, http.StatusOK, styles)
+	})
+	mux.HandleFunc("PUT /api/style", func(w http.ResponseWriter, r *http.Request) {
+		var request SetChatStyleRequest
+		if !readJSON(w, r, &request) {
+			return
+		}
+		if web_server.GetChatStyleFromId(request.Id) == nil {
+			writeError(w, http.StatusNotFound, "style not found")
+			return
+		}
+		controller.SetChatStyle(request.Id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("PUT /api/styles/{id}/css", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := getStyleId(w, r)
+		if !ok {
+			return
+		}
+		var request SetChatStyleCSSRequest
+		if !readJSON(w, r, &request) {
+			return
+		}
+		controller.SetChatStyleCustomCSS(id, request.CSS)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /api/styles/{id}/css", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := getStyleId(w, r)
+		if !ok {
+			return
+		}
+		controller.ResetChatStyleCustomCSS(id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Println("[control_api] Control API ready at /api/")
+	return mux
+}
+
+// normalizeChannel aplica as mesmas regras da janela, links do YouTube viram o identificador salvo
+func normalizeChannel(platform chat_stream.PlatformType, channel string) (string, bool) {
+	invalidChars, ok := chat_stream.ChannelInvalidChars[platform]
+	if !ok {
+		return "", false
+	}
+	channel = invalidChars.ReplaceAllString(strings.TrimSpace(channel), "")
+	if channel == "" || len(channel) > MAX_CHANNEL_LENGTH {
+		return "", false
+	}
+	if platform == chat_stream.PlatformTypeYoutube {
+		channel = chat_stream.NormalizeYoutubeInput(channel)
+	}
+	return channel, channel != ""
+}
+
+func hasChannel(controller Controller, platform chat_stream.PlatformType, channel string) bool {
+	for _, status := range controller.GetChannels() {
+		if status.Platform == platform && status.Channel == channel {
+			return true
+		}
+	}
+	return false
+}
+
+func getStyleId(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil || web_server.GetChatStyleFromId(uint(id)) == nil {
+		writeError(w, http.StatusNotFound, "style not found")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, target any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MAX_BODY_BYTES)).Decode(target)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	err := json.NewEncoder(w).Encode(data)
+	if err != nil {
+		log.Println("[control_api] Fail to encode response", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, ErrorResponse{Error: message})
+}
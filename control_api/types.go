@@ -0,0 +1,54 @@
+package control_api
+
+import "overtube/chat_stream"
+
+// Controller executa as mesmas operações da janela, implementado no main repassando os ui.UIEvent
+type Controller interface {
+	AddChannel(platform chat_stream.PlatformType, channel string, label string)
+	RemoveChannel(platform chat_stream.PlatformType, channel string)
+	SetChatStyle(id uint)
+	SetChatStyleCustomCSS(id uint, css string)
+	ResetChatStyleCustomCSS(id uint)
+	GetChannels() []ChannelStatus
+}
+
+type ChannelStatus struct {
+	Platform chat_stream.PlatformType `json:"platform"`
+	Channel  string                   `json:"channel"`
+	Label    string                   `json:"label"`
+	Status   string                   `json:"status"` // stopped, starting, running ou waiting
+	VideoId  string                   `json:"videoId,omitempty"`
+}
+
+type StatusResponse struct {
+	Channels []ChannelStatus `json:"channels"`
+}
+
+type ChatStyle struct {
+	Id    uint   `json:"id"`
+	Label string `json:"label"`
+}
+
+type AddChannelRequest struct {
+	Platform chat_stream.PlatformType `json:"platform"`
+	Channel  string                   `json:"channel"`
+	Label    string                   `json:"label"`
+}
+
+type SetChatStyleRequest struct {
+	Id uint `json:"id"`
+}
+
+type SetChatStyleCSSRequest struct {
+	CSS string `json:"css"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+const (
+	MAX_CHANNEL_LENGTH = 100
+	MAX_LABEL_LENGTH   = 30
+	MAX_BODY_BYTES     = 256 * 1024
+)
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"overtube/ui"
+	"syscall"
+)
+
+// runHeadless troca a janela pelo stdout: os comandos que iriam para a interface viram linhas de log
+// e o app encerra com Ctrl+C ou SIGTERM, o controle fica pela API em /api/
+func runHeadless(uiEventChan chan<- ui.UIEvent) {
+	log.SetOutput(os.Stdout)
+	log.Println("[headless] Running without the window, overlay at", appState.GetOverlayURL())
+	go logUICommands()
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		received := <-signals
+		log.Println("[headless] Received", received, "shutting down")
+		uiEventChan <- ui.UIEventExit{}
+	}()
+}
+
+func logUICommands() {
+	for cmd := range uiCommandsChan {
+		switch t := cmd.(type) {
+		case ui.ChannelConnectionStatusChange:
+			log.Println("[headless]", t.Platform, "channel:", t.Channel, "status:", t.Status)
+		case ui.ChannelVideoChange:
+			log.Println("[headless]", t.Platform, "channel:", t.Channel, "attached to video", t.VideoId)
+		case ui.ServerError:
+			log.Println("[headless] Server error:", t.Message)
+		case ui.OverlayURLChange:
+			log.Println("[headless] Overlay URL changed to", t.URL)
+		}
+	}
+}
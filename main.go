@@ -1,53 +1,192 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"overtube/chat_stream"
+	"overtube/control_api"
 	"overtube/save_state"
 	"overtube/ui"
 	"overtube/web_server"
+	"overtube/webhooks"
 	"overtube/ws_server"
 	"reflect"
+	"time"
 )
 
 var appState = save_state.Read()
-var wsServer = ws_server.CreateServer()
+var wsServer = ws_server.CreateServer(appState)
 var webServer = web_server.CreateServer(appState)
+var webhookDispatcher = webhooks.NewDispatcher(webhooks.DELIVERY_LOG_FILE)
 var uiCommandsChan = make(chan ui.UICommand)
+var channelStatuses = newChannelStatusTracker()
+
+var replayFile = flag.String("replay", "", "Arquivo .jsonl gravado para reproduzir no overlay")
+var replaySpeed = flag.Float64("replay-speed", 1, "Velocidade da reprodução do arquivo de -replay")
+var headless = flag.Bool("headless", false, "Roda sem a janela, os status vão para o stdout e o controle fica pela API")
+
+// Intervalo entre as tentativas de reconectar os canais que caíram
+const RECONNECT_INTERVAL = 2 * time.Second
 
 func main() {
+	flag.Parse()
 	uiEventChan := make(chan ui.UIEvent)
-	go ui.CreateHomeWindow(uiEventChan, uiCommandsChan, appState)
+	if *headless || !startWindow(uiEventChan) {
+		runHeadless(uiEventChan)
+	}
 	go handleUICommands()
-	orchestrateEvents(uiEventChan)
+	// A API de controle tem o seu próprio canal, o da janela é fechado por ela ao sair
+	controlEventChan := make(chan ui.UIEvent)
+	controlDone := make(chan struct{})
+	startServers(controlEventChan, controlDone)
+	orchestrateEvents(uiEventChan, controlEventChan)
+	// Requisições da API que chegarem daqui em diante não esperam mais pelo orquestrador
+	close(controlDone)
+	// O ws_server para antes para encerrar os streams abertos, senão o servidor da página espera por eles
 	wsServer.Stop()
 	webServer.Stop()
+	webhookDispatcher.Stop()
+}
+
+// startServers sobe o servidor do overlay com o /ws e o /events na mesma porta da página,
+// uma falha (ex: porta em uso) aparece na interface
+func startServers(controlEventChan chan<- ui.UIEvent, controlDone <-chan struct{}) {
+	webServer.HandleOverlay("/ws", http.HandlerFunc(wsServer.ServeWS))
+	webServer.HandleOverlay("/events", http.HandlerFunc(wsServer.ServeSSE))
+	webServer.HandleControl("/api/", control_api.NewHandler(newControlAdapter(controlEventChan, controlDone, channelStatuses)))
+	err := webServer.Start()
+	if err != nil {
+		notifyServerError(fmt.Sprintf("Não foi possível iniciar o servidor do overlay na porta %d: %s", appState.WebPort, err.Error()))
+	}
+}
+
+func notifyServerError(message string) {
+	log.Println(message)
+	go func() {
+		uiCommandsChan <- ui.ServerError{Message: message}
+	}()
 }
 
 func handleUICommands() {
 	for {
-		statusEvent, more := <-wsServer.StatusEventChan
-		if !more {
-			log.Println("StatusEventChan event channel closed")
-			break
-		}
-		uiCommandsChan <- ui.ChannelConnectionStatusChange{
-			Platform: statusEvent.Platform,
-			Status:   statusEvent.Status,
+		select {
+		case statusEvent := <-wsServer.StatusEventChan:
+			setChannelStatus(statusEvent.Platform, statusEvent.Channel, statusEvent.Status)
+		case <-wsServer.Done():
+			log.Println("WS server stopped, no more status events")
+			return
 		}
 	}
 }
 
-func orchestrateEvents(uiEventChan chan ui.UIEvent) {
-	var ytChatStream chat_stream.ChatStreamCon = nil
-	var twChatStream chat_stream.ChatStreamCon = nil
+func orchestrateEvents(uiEventChan chan ui.UIEvent, controlEventChan <-chan ui.UIEvent) {
+	// Conexões ativas indexadas por "plataforma/canal"
+	chatStreams := make(map[string]chat_stream.ChatStreamCon)
+	// Canais conectando ou aguardando a live começar, o canal de stop cancela a tentativa
+	pendingConnections := make(map[string]chan struct{})
+	connectionResults := make(chan channelConnectResult)
 
 	webServer.SetSelectedChatStyle(web_server.GetChatStyleFromId(appState.ChatStyleId))
+	wsServer.SetRecording(appState.RecordChat)
+	wsServer.SetBacklogSize(int(appState.BacklogSize))
+	wsServer.SetImageURLRewriter(web_server.ProxyImageURL)
+	webhookDispatcher.SetTargets(appState.Webhooks)
+	wsServer.SetPublishListener(func(published ws_server.PublishedPayload) {
+		webhookDispatcher.Dispatch(webhooks.Event{
+			Kind:     published.Kind,
+			Platform: string(published.Platform),
+			Channel:  published.Channel,
+			Text:     published.Text,
+			Payload:  published.Data,
+		})
+	})
+	if *replayFile != "" {
+		replayStreams, err := chat_stream.ReplayChatFromFile(*replayFile, *replaySpeed)
+		if err != nil {
+			log.Println("Failed to open chat replay:", *replayFile, err)
+		}
+		for _, replayStream := range replayStreams {
+			chatStreams[chatStreamKey(replayStream.GetPlatform(), replayStream.GetChannelId())] = replayStream
+			wsServer.AddStream(replayStream, replayStream.Label)
+		}
+	}
+
+	// addChannel conecta (ou reconecta) um canal, usado pela janela, pela API e pela reconexão automática.
+	// A conexão é aberta fora deste loop, o resultado volta por connectionResults
+	addChannel := func(v ui.UIEventAddChannel) {
+		key := chatStreamKey(v.Platform, v.Channel)
+		stopPendingConnection(pendingConnections, key)
+		closeChatStream(chatStreams[key])
+		delete(chatStreams, key)
+		channelStatuses.track(v.Platform, v.Channel, v.Label)
+		setChannelStatus(v.Platform, v.Channel, ws_server.ChannelConnectionStarting)
+		stop := make(chan struct{})
+		pendingConnections[key] = stop
+		go connectChannel(v, stop, connectionResults)
+	}
+	// Canais salvos conectam ao abrir, depois só voltam a cada RECONNECT_INTERVAL os que já conectaram alguma vez
+	channelStatuses.restore(appState.Channels)
+	for _, channel := range appState.Channels {
+		addChannel(ui.UIEventAddChannel{
+			Platform: chat_stream.PlatformType(channel.Platform),
+			Channel:  channel.Channel,
+			Label:    channel.Label,
+		})
+	}
+	reconnectTicker := time.NewTicker(RECONNECT_INTERVAL)
+	defer reconnectTicker.Stop()
 
 	for {
-		event, more := <-uiEventChan
-		if !more {
-			log.Println("UI event channel closed")
+		var event ui.UIEvent
+		select {
+		case result := <-connectionResults:
+			v := result.event
+			key := chatStreamKey(v.Platform, v.Channel)
+			if pendingConnections[key] != result.stop {
+				// A tentativa foi cancelada enquanto a conexão era aberta
+				closeChatStream(result.stream)
+				continue
+			}
+			if result.err == chat_stream.ErrStreamNotLive {
+				log.Println(v.Platform, "channel", v.Channel, "is not live, waiting for the stream to start")
+				setChannelStatus(v.Platform, v.Channel, ws_server.ChannelConnectionWaiting)
+				appState.SetChannel(string(v.Platform), v.Channel, v.Label)
+				save_state.Save(appState)
+				continue
+			}
+			delete(pendingConnections, key)
+			if result.err != nil {
+				log.Println("Failed to connect to", v.Platform, "chat:", v.Channel, result.err)
+				setChannelStatus(v.Platform, v.Channel, ws_server.ChannelConnectionStopped)
+				continue
+			}
+			chatStreams[key] = result.stream
+			wsServer.AddStream(result.stream, v.Label)
+			notifyAttachedVideo(v.Platform, v.Channel, result.stream)
+			appState.SetChannel(string(v.Platform), v.Channel, v.Label)
+			save_state.Save(appState)
+			continue
+		case <-reconnectTicker.C:
+			for _, channel := range channelStatuses.toReconnect() {
+				log.Println("Retrying connection to", channel.Platform, "channel:", channel.Channel)
+				addChannel(ui.UIEventAddChannel{
+					Platform: channel.Platform,
+					Channel:  channel.Channel,
+					Label:    channel.Label,
+				})
+			}
+			continue
+		case e, more := <-uiEventChan:
+			if !more {
+				log.Println("UI event channel closed")
+			}
+			event = e
+		case e := <-controlEventChan:
+			event = e
+		}
+		if event == nil {
 			break
 		}
 		if event.GetError() != nil {
@@ -55,54 +194,41 @@ func orchestrateEvents(uiEventChan chan ui.UIEvent) {
 		}
 
 		switch v := event.(type) {
-		case ui.UIEventSetYoutubeChannel:
-			closeChatStream(ytChatStream)
-			uiCommandsChan <- ui.ChannelConnectionStatusChange{
-				Platform: chat_stream.PlatformTypeYoutube,
-				Status:   ws_server.ChannelConnectionStarting,
-			}
-			var err error = nil
-			ytChatStream, err = chat_stream.ConnectToYoutubeChat(v.Channel)
-			if err != nil {
-				log.Println("Failed to connect to YouTube chat:", v.Channel, err)
-				uiCommandsChan <- ui.ChannelConnectionStatusChange{
-					Platform: chat_stream.PlatformTypeYoutube,
-					Status:   ws_server.ChannelConnectionStopped,
-				}
+		case ui.UIEventAddChannel:
+			addChannel(v)
+		case ui.UIEventRemoveChannel:
+			key := chatStreamKey(v.Platform, v.Channel)
+			appState.RemoveChannel(string(v.Platform), v.Channel)
+			save_state.Save(appState)
+			channelStatuses.untrack(v.Platform, v.Channel)
+			stopPendingConnection(pendingConnections, key)
+			wsServer.RemoveStream(v.Platform, v.Channel)
+			closeChatStream(chatStreams[key])
+			delete(chatStreams, key)
+		case ui.UIEventSetSimulator:
+			key := chatStreamKey(chat_stream.PlatformTypeSimulator, "simulator")
+			simulator, running := chatStreams[key].(*chat_stream.SimulatorChatStreamCon)
+			if !v.Enabled {
+				wsServer.RemoveStream(chat_stream.PlatformTypeSimulator, "simulator")
+				closeChatStream(chatStreams[key])
+				delete(chatStreams, key)
+			} else if running && simulator.IsConnected() {
+				simulator.SetMessagesPerSecond(v.MessagesPerSecond)
 			} else {
-				wsServer.AddStream(ytChatStream)
-				appState.YoutubeChannel = v.Channel
-				save_state.Save(appState)
-			}
-		case ui.UIEventSetTwitchChannel:
-			closeChatStream(twChatStream)
-			uiCommandsChan <- ui.ChannelConnectionStatusChange{
-				Platform: chat_stream.PlatformTypeTwitch,
-				Status:   ws_server.ChannelConnectionStarting,
-			}
-			var err error = nil
-			twChatStream, err = chat_stream.ConnectToTwitchChat(v.Channel)
-			if err != nil {
-				log.Println("Failed to connect to Twitch chat:", v.Channel, err)
-				uiCommandsChan <- ui.ChannelConnectionStatusChange{
-					Platform: chat_stream.PlatformTypeTwitch,
-					Status:   ws_server.ChannelConnectionStopped,
+				config := chat_stream.DefaultSimulatorConfig
+				config.MessagesPerSecond = v.MessagesPerSecond
+				chatStream, err := chat_stream.ConnectToSimulatedChat(config)
+				if err != nil {
+					log.Println("Failed to start simulated chat:", err)
+				} else {
+					chatStreams[key] = chatStream
+					wsServer.AddStream(chatStream, "")
 				}
-			} else {
-				wsServer.AddStream(twChatStream)
-				appState.TwitchChannel = v.Channel
-				save_state.Save(appState)
 			}
-		case ui.UIEventRemoveYoutubeChannel:
-			appState.YoutubeChannel = ""
+		case ui.UIEventSetRecordChat:
+			appState.RecordChat = v.Enabled
 			save_state.Save(appState)
-			wsServer.RemoveAllStreamsFromPlatform(chat_stream.PlatformTypeYoutube)
-			closeChatStream(ytChatStream)
-		case ui.UIEventRemoveTwitchChannel:
-			appState.TwitchChannel = ""
-			save_state.Save(appState)
-			wsServer.RemoveAllStreamsFromPlatform(chat_stream.PlatformTypeTwitch)
-			closeChatStream(twChatStream)
+			wsServer.SetRecording(v.Enabled)
 		case ui.UIEventSetChatStyle:
 			webServer.SetSelectedChatStyle(web_server.GetChatStyleFromId(v.Id))
 			appState.ChatStyleId = v.Id
@@ -116,15 +242,116 @@ func orchestrateEvents(uiEventChan chan ui.UIEvent) {
 			appState.ResetChatStyleCustomCSS(v.Id)
 			save_state.Save(appState)
 			wsServer.RefreshClients()
+		case ui.UIEventRotateTokens:
+			appState.RotateTokens()
+			save_state.Save(appState)
+			webServer.SetTokens(appState.OverlayToken, appState.ControlToken)
+			wsServer.DisconnectClients()
+			uiCommandsChan <- ui.OverlayURLChange{URL: appState.GetOverlayURL()}
 		case ui.UIEventExit:
 			log.Println("User exited")
 		default:
 			log.Println("Unknown event type: ", reflect.TypeOf(event))
 		}
+		if _, exit := event.(ui.UIEventExit); exit {
+			break
+		}
+	}
+
+	for key := range pendingConnections {
+		stopPendingConnection(pendingConnections, key)
+	}
+	for _, chatStream := range chatStreams {
+		closeChatStream(chatStream)
+	}
+}
+
+type channelConnectResult struct {
+	event  ui.UIEventAddChannel
+	stop   chan struct{}
+	stream chat_stream.ChatStreamCon
+	err    error
+}
+
+// connectChannel abre a conexão fora do orquestrador, uma página lenta do YouTube não trava a janela
+// nem a API. Se a live ainda não começou avisa com ErrStreamNotLive e continua esperando por ela
+func connectChannel(event ui.UIEventAddChannel, stop chan struct{}, results chan<- channelConnectResult) {
+	chatStream, err := connectToChannel(event.Platform, event.Channel)
+	if !sendConnectResult(results, channelConnectResult{event: event, stop: stop, stream: chatStream, err: err}) {
+		return
+	}
+	if err != chat_stream.ErrStreamNotLive {
+		return
+	}
+	chatStream, err = chat_stream.WaitForYoutubeLive(event.Channel, stop)
+	if err != nil {
+		log.Println("Stopped waiting for", event.Platform, "channel:", event.Channel)
+		return
+	}
+	log.Println(event.Platform, "channel", event.Channel, "is live now")
+	sendConnectResult(results, channelConnectResult{event: event, stop: stop, stream: chatStream})
+}
+
+// sendConnectResult entrega o resultado ao orquestrador, ou fecha a conexão se a tentativa foi cancelada
+func sendConnectResult(results chan<- channelConnectResult, result channelConnectResult) bool {
+	select {
+	case results <- result:
+		return true
+	case <-result.stop:
+		closeChatStream(result.stream)
+		return false
 	}
+}
 
-	closeChatStream(ytChatStream)
-	closeChatStream(twChatStream)
+func stopPendingConnection(pendingConnections map[string]chan struct{}, key string) {
+	stop, ok := pendingConnections[key]
+	if !ok {
+		return
+	}
+	close(stop)
+	delete(pendingConnections, key)
+}
+
+func chatStreamKey(platform chat_stream.PlatformType, channel string) string {
+	return string(platform) + "/" + channel
+}
+
+func connectToChannel(platform chat_stream.PlatformType, channel string) (chat_stream.ChatStreamCon, error) {
+	switch platform {
+	case chat_stream.PlatformTypeYoutube:
+		return chat_stream.ConnectToYoutubeChat(channel)
+	case chat_stream.PlatformTypeTwitch:
+		return chat_stream.ConnectToTwitchChat(channel)
+	case chat_stream.PlatformTypeKick:
+		return chat_stream.ConnectToKickChat(channel)
+	default:
+		return nil, fmt.Errorf("unknown platform: %s", platform)
+	}
+}
+
+// notifyAttachedVideo informa à UI qual vídeo foi de fato conectado, já que o canal pode ter sido
+// resolvido a partir de um handle, ID de canal ou link
+func notifyAttachedVideo(platform chat_stream.PlatformType, channel string, chatStream chat_stream.ChatStreamCon) {
+	ytChatStream, ok := chatStream.(*chat_stream.YTChatStreamCon)
+	if !ok {
+		return
+	}
+	channelStatuses.setVideoId(platform, channel, ytChatStream.GetVideoId())
+	uiCommandsChan <- ui.ChannelVideoChange{
+		Platform: platform,
+		Channel:  channel,
+		VideoId:  ytChatStream.GetVideoId(),
+	}
+}
+
+// setChannelStatus atualiza a janela e o status consultado pela API de controle
+func setChannelStatus(platform chat_stream.PlatformType, channel string, status ws_server.ChannelConnectionStatus) {
+	channelStatuses.setStatus(platform, channel, status)
+	uiCommandsChan <- ui.ChannelConnectionStatusChange{
+		Platform: platform,
+		Channel:  channel,
+		Status:   status,
+	}
 }
 
 func closeChatStream(chatStream chat_stream.ChatStreamCon) {
@@ -0,0 +1,42 @@
+package save_state
+
+import "net"
+
+// IsLoopbackAddress diz se o endereço só aceita conexões deste PC
+func IsLoopbackAddress(address string) bool {
+	if address == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsLoopback()
+}
+
+// getLANAddress devolve o endereço que outro PC da rede usa para chegar ao servidor. Um IP específico
+// é usado como está, com "0.0.0.0" ou vazio procura o IPv4 da rede local, de preferência um privado
+func getLANAddress(bindAddress string) string {
+	ip := net.ParseIP(bindAddress)
+	if bindAddress != "" && (ip == nil || !ip.IsUnspecified()) {
+		return bindAddress
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "localhost"
+	}
+	var fallback string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.IsPrivate() {
+			return ipNet.IP.String()
+		}
+		if fallback == "" {
+			fallback = ipNet.IP.String()
+		}
+	}
+	if fallback == "" {
+		return "localhost"
+	}
+	return fallback
+}
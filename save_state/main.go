@@ -1,13 +1,20 @@
 package save_state
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"log"
 	"os"
 	"reflect"
 )
 
-const STATE_FILE_NAME = "overtube_state.json"
+const (
+	STATE_FILE_NAME      = "overtube_state.json"
+	DEFAULT_BACKLOG_SIZE = 50
+	DEFAULT_BIND_ADDRESS = "127.0.0.1"
+	DEFAULT_WEB_PORT     = 1337
+)
 
 func Save(data *AppState) bool {
 	dataJson, err := json.Marshal(data)
@@ -16,26 +23,37 @@ func Save(data *AppState) bool {
 		return false
 	}
 
-	err = os.WriteFile(STATE_FILE_NAME, dataJson, 0666)
+	// O arquivo guarda os tokens e os segredos dos webhooks, só o dono pode ler
+	err = os.WriteFile(STATE_FILE_NAME, dataJson, 0600)
 	if err != nil {
 		log.Println(err)
 		return false
 	}
+	// WriteFile não muda a permissão de um arquivo que já existia
+	os.Chmod(STATE_FILE_NAME, 0600)
 
 	return true
 }
 
 func Read() *AppState {
 	defaultState := &AppState{
-		YoutubeChannel:      "",
-		TwitchChannel:       "",
+		Channels:            []ChannelConfig{},
 		ChatStyleId:         1,
 		ChatStyleCustomCSSs: []ChatStyleCustomCSS{},
+		BacklogSize:         DEFAULT_BACKLOG_SIZE,
+		Webhooks:            []WebhookConfig{},
+		BindAddress:         DEFAULT_BIND_ADDRESS,
+		WebPort:             DEFAULT_WEB_PORT,
+		AllowedOrigins:      []string{},
+		OverlayToken:        NewToken(),
+		ControlToken:        NewToken(),
 	}
 
 	dataJson, err := os.ReadFile(STATE_FILE_NAME)
 	if err != nil {
 		log.Println("[save_state::Read] Fail to read file", err)
+		// Salva já com os tokens, senão cada execução geraria links diferentes
+		Save(defaultState)
 		return defaultState
 	}
 	var readedData map[string]any
@@ -46,15 +64,38 @@ func Read() *AppState {
 	}
 
 	readedState := &AppState{
-		YoutubeChannel:      getDataOrDefault(readedData, "YoutubeChannel", "").(string),
-		TwitchChannel:       getDataOrDefault(readedData, "TwitchChannel", "").(string),
+		Channels:            getChannels(readedData),
 		ChatStyleId:         uint(getDataOrDefault(readedData, "ChatStyleId", float64(1)).(float64)),
 		ChatStyleCustomCSSs: getCSSCustoms(readedData),
+		RecordChat:          getDataOrDefault(readedData, "RecordChat", false).(bool),
+		BacklogSize:         uint(getDataOrDefault(readedData, "BacklogSize", float64(DEFAULT_BACKLOG_SIZE)).(float64)),
+		Webhooks:            getWebhooks(readedData),
+		BindAddress:         getDataOrDefault(readedData, "BindAddress", DEFAULT_BIND_ADDRESS).(string),
+		WebPort:             uint(getDataOrDefault(readedData, "WebPort", float64(DEFAULT_WEB_PORT)).(float64)),
+		AllowedOrigins:      getStringList(readedData, "AllowedOrigins"),
+		OverlayToken:        getDataOrDefault(readedData, "OverlayToken", "").(string),
+		ControlToken:        getDataOrDefault(readedData, "ControlToken", "").(string),
+	}
+	if readedState.OverlayToken == "" || readedState.ControlToken == "" {
+		if readedState.OverlayToken == "" {
+			readedState.OverlayToken = NewToken()
+		}
+		if readedState.ControlToken == "" {
+			readedState.ControlToken = NewToken()
+		}
+		Save(readedState)
 	}
 
 	return readedState
 }
 
+// NewToken gera um segredo aleatório para os links do overlay e para a API de controle
+func NewToken() string {
+	token := make([]byte, 24)
+	rand.Read(token)
+	return hex.EncodeToString(token)
+}
+
 func getDataOrDefault(readedData map[string]any, key string, defaultValue any) any {
 	if readedData[key] == nil {
 		return defaultValue
@@ -80,3 +121,76 @@ func getCSSCustoms(readedData map[string]any) []ChatStyleCustomCSS {
 	}
 	return list
 }
+
+func getStringList(readedData map[string]any, key string) []string {
+	list := []string{}
+	items, ok := readedData[key].([]any)
+	if !ok {
+		return list
+	}
+	for _, item := range items {
+		if value, ok := item.(string); ok {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func getWebhooks(readedData map[string]any) []WebhookConfig {
+	list := make([]WebhookConfig, 0)
+	items, ok := readedData["Webhooks"].([]any)
+	if !ok {
+		return list
+	}
+	for _, item := range items {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		list = append(list, WebhookConfig{
+			Id:             getDataOrDefault(entry, "Id", "").(string),
+			URL:            getDataOrDefault(entry, "URL", "").(string),
+			Secret:         getDataOrDefault(entry, "Secret", "").(string),
+			Kinds:          getStringList(entry, "Kinds"),
+			MessagePattern: getDataOrDefault(entry, "MessagePattern", "").(string),
+			Enabled:        getDataOrDefault(entry, "Enabled", true).(bool),
+		})
+	}
+	return list
+}
+
+func getChannels(readedData map[string]any) []ChannelConfig {
+	list := make([]ChannelConfig, 0)
+	if items, ok := readedData["Channels"].([]any); ok {
+		for _, item := range items {
+			entry, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			list = append(list, ChannelConfig{
+				Platform: getDataOrDefault(entry, "Platform", "").(string),
+				Channel:  getDataOrDefault(entry, "Channel", "").(string),
+				Label:    getDataOrDefault(entry, "Label", "").(string),
+			})
+		}
+		return list
+	}
+
+	// Versões antigas guardavam um único canal por plataforma
+	legacyKeys := map[string]string{
+		"YoutubeChannel": "youtube",
+		"TwitchChannel":  "twitch",
+		"KickChannel":    "kick",
+	}
+	for _, key := range []string{"YoutubeChannel", "TwitchChannel", "KickChannel"} {
+		channel, ok := readedData[key].(string)
+		if !ok || channel == "" {
+			continue
+		}
+		list = append(list, ChannelConfig{
+			Platform: legacyKeys[key],
+			Channel:  channel,
+		})
+	}
+	return list
+}
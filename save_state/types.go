@@ -1,15 +1,59 @@
 package save_state
 
+import (
+	"net"
+	"strconv"
+)
+
 type ChatStyleCustomCSS struct {
 	Id  uint
 	CSS string
 }
 
+type ChannelConfig struct {
+	Platform string
+	Channel  string
+	Label    string
+}
+
+// WebhookConfig é um destino que recebe POSTs com os tipos escolhidos de mensagens e eventos
+type WebhookConfig struct {
+	Id             string
+	URL            string
+	Secret         string   // Assina o corpo com HMAC-SHA256, vazio não assina
+	Kinds          []string // "msg" ou tipos de evento (sub, superchat, raid...)
+	MessagePattern string   // Regex para as mensagens, vazio aceita todas
+	Enabled        bool
+}
+
 type AppState struct {
-	YoutubeChannel      string
-	TwitchChannel       string
+	Channels            []ChannelConfig
 	ChatStyleId         uint
 	ChatStyleCustomCSSs []ChatStyleCustomCSS
+	RecordChat          bool
+	BacklogSize         uint // Mensagens recentes reenviadas ao overlay quando ele conecta
+	Webhooks            []WebhookConfig
+	BindAddress         string   // "0.0.0.0" libera o acesso pela rede local, ex: OBS em outro PC
+	WebPort             uint     // Página do overlay, o WebSocket e a API usam a mesma porta
+	AllowedOrigins      []string // Origens extras aceitas no WebSocket, "*" aceita qualquer uma
+	OverlayToken        string   // Vai nos links do overlay, exigido quando o servidor está aberto para a rede
+	ControlToken        string   // Exigido sempre pela API de controle
+}
+
+// GetOverlayURL devolve o link do overlay já com o token na query. Com o servidor aberto para a rede o
+// link usa o endereço da rede local, assim funciona também no OBS de outro PC
+func (s *AppState) GetOverlayURL() string {
+	host := "localhost"
+	if !IsLoopbackAddress(s.BindAddress) {
+		host = getLANAddress(s.BindAddress)
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(int(s.WebPort))) + "/?token=" + s.OverlayToken
+}
+
+// RotateTokens troca os dois tokens, links copiados antes deixam de funcionar
+func (s *AppState) RotateTokens() {
+	s.OverlayToken = NewToken()
+	s.ControlToken = NewToken()
 }
 
 func (s *AppState) SetChatStyleCustomCSS(id uint, css string) {
@@ -34,3 +78,27 @@ func (s *AppState) ResetChatStyleCustomCSS(id uint) {
 	}
 	s.ChatStyleCustomCSSs = filtered
 }
+
+func (s *AppState) SetChannel(platform string, channel string, label string) {
+	for i, opt := range s.Channels {
+		if opt.Platform == platform && opt.Channel == channel {
+			s.Channels[i].Label = label
+			return
+		}
+	}
+	s.Channels = append(s.Channels, ChannelConfig{
+		Platform: platform,
+		Channel:  channel,
+		Label:    label,
+	})
+}
+
+func (s *AppState) RemoveChannel(platform string, channel string) {
+	filtered := []ChannelConfig{}
+	for _, opt := range s.Channels {
+		if opt.Platform != platform || opt.Channel != channel {
+			filtered = append(filtered, opt)
+		}
+	}
+	s.Channels = filtered
+}
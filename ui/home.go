@@ -2,6 +2,7 @@ package ui
 
 import (
 	"embed"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
@@ -12,58 +13,41 @@ import (
 	"overtube/save_state"
 	"overtube/web_server"
 	"overtube/ws_server"
-	"regexp"
 	"strings"
 	"time"
 
-	"gioui.org/app"
 	"gioui.org/io/clipboard"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
-	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"gioui.org/text"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
-	"golang.org/x/sys/windows"
 )
 
 //go:embed platform_icons/*
 var platformIcons embed.FS
 
-func CreateHomeWindow(uiEvents chan<- UIEvent, uiCommands <-chan UICommand, appState *save_state.AppState) {
-	go func() {
-		window := &app.Window{}
-		window.Option(app.Title("OverTube"))
-		window.Option(app.MinSize(400, 300))
-		window.Option(app.Size(800, 660))
-		err := run(window, uiEvents, uiCommands, appState)
-		uiEvents <- UIEventExit{err: err}
-		close(uiEvents)
-	}()
-	app.Main()
-}
-
 func initialState() *UIState {
 	state := &UIState{}
 	state.MainList = &widget.List{}
 	state.MainList.Axis = layout.Vertical
 
-	state.YoutubeChannelURLEditor = &widget.Editor{}
-	state.YoutubeChannelURLEditor.SingleLine = true
-	state.YoutubeChannelURLEditor.MaxLen = 60
-	state.YouTubeChannelClickable = &widget.Clickable{}
-
-	state.TwitchChannelURLEditor = &widget.Editor{}
-	state.TwitchChannelURLEditor.SingleLine = true
-	state.TwitchChannelURLEditor.MaxLen = 60
-	state.TwitchChannelClickable = &widget.Clickable{}
+	state.PlatformInputs = []*PlatformInputState{
+		newPlatformInputState(chat_stream.PlatformTypeYoutube, "YouTube @Channel, ID do canal, ID ou link do vídeo", "platform_icons/yt.png", 0.6, "Copiar link para o chat (YouTube)"),
+		newPlatformInputState(chat_stream.PlatformTypeTwitch, "Twitch username", "platform_icons/tw.png", 0.45, "Copiar link para o chat (Twitch)"),
+		newPlatformInputState(chat_stream.PlatformTypeKick, "Kick username", "platform_icons/kick.png", 0.45, "Copiar link para o chat (Kick)"),
+	}
+	state.PlatformInputs[0].Normalize = chat_stream.NormalizeYoutubeInput
+	state.Channels = []*ChannelState{}
 
 	state.CopyLinkToChatClickable = &widget.Clickable{}
-	state.CopyLinkToYTClickable = &widget.Clickable{}
-	state.CopyLinkToTwClickable = &widget.Clickable{}
+	state.RotateTokensClickable = &widget.Clickable{}
+	state.RecordChatBool = &widget.Bool{}
+	state.SimulatorBool = &widget.Bool{}
+	state.SimulatorRateFloat = &widget.Float{Value: 0.1}
 	state.VersionClickable = &widget.Clickable{}
 	state.ConfirmCSSClickable = &widget.Clickable{}
 	state.RevertCSSClickable = &widget.Clickable{}
@@ -81,17 +65,43 @@ func initialState() *UIState {
 	return state
 }
 
-func readAppState(state *UIState, appState save_state.AppState) {
-	if len(appState.YoutubeChannel) > 0 {
-		state.YoutubeChannelURLEditor.SetText(appState.YoutubeChannel)
-		state.YoutubeChannelSet = appState.YoutubeChannel
-		state.YoutubeChannelWasConnected = true
+func newPlatformInputState(
+	platform chat_stream.PlatformType,
+	placeholder string,
+	iconPath string,
+	iconScale float32,
+	copyLinkLabel string,
+) *PlatformInputState {
+	input := &PlatformInputState{
+		Platform:          platform,
+		Placeholder:       placeholder,
+		IconPath:          iconPath,
+		IconScale:         iconScale,
+		InvalidChars:      chat_stream.ChannelInvalidChars[platform],
+		ChannelEditor:     &widget.Editor{},
+		LabelEditor:       &widget.Editor{},
+		AddClickable:      &widget.Clickable{},
+		CopyLinkLabel:     copyLinkLabel,
+		CopyLinkClickable: &widget.Clickable{},
 	}
-	if len(appState.TwitchChannel) > 0 {
-		state.TwitchChannelURLEditor.SetText(appState.TwitchChannel)
-		state.TwitchChannelSet = appState.TwitchChannel
-		state.TwitchChannelWasConnected = true
+	input.ChannelEditor.SingleLine = true
+	input.ChannelEditor.MaxLen = 100
+	input.LabelEditor.SingleLine = true
+	input.LabelEditor.MaxLen = 30
+	return input
+}
+
+func readAppState(state *UIState, appState save_state.AppState) {
+	for _, channel := range appState.Channels {
+		state.AddChannel(&ChannelState{
+			Platform:        chat_stream.PlatformType(channel.Platform),
+			Channel:         channel.Channel,
+			Label:           channel.Label,
+			RemoveClickable: &widget.Clickable{},
+		})
 	}
+	state.RecordChatBool.Value = appState.RecordChat
+	state.OverlayURL = appState.GetOverlayURL()
 	if appState.ChatStyleId > 0 {
 		state.ChatStyleId = appState.ChatStyleId
 	}
@@ -103,140 +113,119 @@ func readAppState(state *UIState, appState save_state.AppState) {
 	}
 }
 
-func run(window *app.Window, uiEvents chan<- UIEvent, uiCommands <-chan UICommand, appState *save_state.AppState) error {
-	theme := material.NewTheme()
-	state := initialState()
-	readAppState(state, *appState)
-	go listenToCommands(window, state, uiCommands)
-	go retryEngine(state, uiEvents)
-	var ops op.Ops
-	for {
-		switch e := window.Event().(type) {
-		case app.DestroyEvent:
-			state.UIClosed = true
-			return e.Err
-		case app.FrameEvent:
-			gtx := app.NewContext(&ops, e)
-
-			emitEvents(gtx, state, uiEvents)
-
-			// Main component layout
-			state.MainList.Layout(gtx, 8, func(gtx layC, index int) layD {
-				switch index {
-				case 0:
-					return renderTitle(gtx, theme, state)
-				case 1:
-					return renderYoutubeChannelInput(gtx, theme, state)
-				case 2:
-					return renderTwichChannelInput(gtx, theme, state)
-				case 3:
-					return renderBtnCopyLinkToChat(gtx, theme, state)
-				case 4:
-					return renderCustomSectionLineSeparator(gtx, theme)
-				case 5:
-					return renderCustomizeSection(gtx, theme, state)
-				case 6:
-					return renderCSSInputSection(gtx, theme, state)
-				case 7:
-					return renderCSSInputConfirmBtns(gtx, theme, state)
-				default:
-					return layout.Dimensions{}
-				}
-			})
-
-			e.Frame(gtx.Ops)
-		}
-	}
+// invalidator é a janela, handleCommand só precisa pedir para redesenhar
+type invalidator interface {
+	Invalidate()
 }
 
-func listenToCommands(w *app.Window, state *UIState, uiCommands <-chan UICommand) {
-	for {
-		cmd, more := <-uiCommands
-		if !more {
-			log.Println("uiCommands event channel closed")
-			break
+func handleCommand(w invalidator, state *UIState, cmd UICommand) {
+	switch t := cmd.(type) {
+	case ChannelConnectionStatusChange:
+		channel := state.FindChannel(t.Platform, t.Channel)
+		if channel == nil {
+			return
 		}
-		handleCommand(w, state, cmd)
-	}
-}
-
-func retryEngine(state *UIState, uiEvents chan<- UIEvent) {
-	if state.UIClosed {
-		log.Println("UI closed, stopping retry engine")
-		return
-	}
-
-	if state.YoutubeChannelWasConnected &&
-		state.YoutubeChannelSet != "" &&
-		state.YoutubeConnStatus == ws_server.ChannelConnectionStopped {
-		log.Println("Retrying connection to YouTube channel:", state.YoutubeChannelSet)
-		uiEvents <- UIEventSetYoutubeChannel{
-			Channel: state.YoutubeChannelSet,
+		channel.ConnStatus = t.Status
+		w.Invalidate()
+	case ChannelVideoChange:
+		channel := state.FindChannel(t.Platform, t.Channel)
+		if channel == nil {
+			return
 		}
-	}
-
-	if state.TwitchChannelWasConnected &&
-		state.TwitchChannelSet != "" &&
-		state.TwitchConnStatus == ws_server.ChannelConnectionStopped {
-		log.Println("Retrying connection to Twitch channel:", state.TwitchChannelSet)
-		uiEvents <- UIEventSetTwitchChannel{
-			Channel: state.TwitchChannelSet,
+		channel.VideoId = t.VideoId
+		w.Invalidate()
+	case ServerError:
+		state.AddServerError(t.Message)
+		w.Invalidate()
+	case OverlayURLChange:
+		state.OverlayURL = t.URL
+		w.Invalidate()
+	case ChannelAdded:
+		if state.FindChannel(t.Platform, t.Channel) == nil {
+			state.AddChannel(&ChannelState{
+				Platform:        t.Platform,
+				Channel:         t.Channel,
+				Label:           t.Label,
+				ConnStatus:      ws_server.ChannelConnectionStarting,
+				RemoveClickable: &widget.Clickable{},
+			})
 		}
-	}
-
-	time.Sleep(time.Second * 2)
-	defer retryEngine(state, uiEvents)
-}
-
-func handleCommand(w *app.Window, state *UIState, cmd UICommand) {
-	switch t := cmd.(type) {
-	case ChannelConnectionStatusChange:
-		if t.Platform == chat_stream.PlatformTypeYoutube {
-			state.YoutubeConnStatus = t.Status
-			if t.Status == ws_server.ChannelConnectionRunning {
-				state.YoutubeChannelWasConnected = true
-			}
-			w.Invalidate()
+		w.Invalidate()
+	case ChannelRemoved:
+		channel := state.FindChannel(t.Platform, t.Channel)
+		if channel == nil {
+			return
 		}
-		if t.Platform == chat_stream.PlatformTypeTwitch {
-			state.TwitchConnStatus = t.Status
-			if t.Status == ws_server.ChannelConnectionRunning {
-				state.TwitchChannelWasConnected = true
-			}
-			w.Invalidate()
+		state.RemoveChannel(channel)
+		w.Invalidate()
+	case ChatStyleChange:
+		state.ChatStyleId = t.Id
+		w.Invalidate()
+	case ChatStyleCSSChange:
+		editor := state.GetChatStyleCustomCSS(t.Id)
+		if editor == nil {
+			return
 		}
+		editor.SetText(t.CSS)
+		w.Invalidate()
 	}
 }
 
 func emitEvents(gtx layC, state *UIState, uiEvents chan<- UIEvent) {
-	if state.YouTubeChannelClickable.Clicked(gtx) && validateYoutubeChannelURLEditor(state) {
-		if state.YoutubeChannelSet == "" {
-			state.YoutubeChannelSet = state.YoutubeChannelURLEditor.Text()
-			uiEvents <- UIEventSetYoutubeChannel{
-				Channel: state.YoutubeChannelURLEditor.Text(),
+	for _, input := range state.PlatformInputs {
+		if input.AddClickable.Clicked(gtx) && validateChannelEditor(input) {
+			channelName := input.ChannelEditor.Text()
+			if input.Normalize != nil {
+				channelName = input.Normalize(channelName)
 			}
-		} else {
-			state.YoutubeChannelWasConnected = false
-			state.YoutubeChannelSet = ""
-			uiEvents <- UIEventRemoveYoutubeChannel{}
+			label := strings.TrimSpace(input.LabelEditor.Text())
+			if state.FindChannel(input.Platform, channelName) == nil {
+				state.AddChannel(&ChannelState{
+					Platform:        input.Platform,
+					Channel:         channelName,
+					Label:           label,
+					ConnStatus:      ws_server.ChannelConnectionStarting,
+					RemoveClickable: &widget.Clickable{},
+				})
+				uiEvents <- UIEventAddChannel{
+					Platform: input.Platform,
+					Channel:  channelName,
+					Label:    label,
+				}
+			}
+			input.ChannelEditor.SetText("")
+			input.LabelEditor.SetText("")
+		}
+
+		if input.CopyLinkClickable.Clicked(gtx) {
+			gtx.Execute(clipboard.WriteCmd{Data: io.NopCloser(strings.NewReader(state.OverlayURL + "&platform=" + string(input.Platform)))})
+			input.CopyLinkClicked = true
+			go func() {
+				time.Sleep(time.Second * 2)
+				input.CopyLinkClicked = false
+			}()
+		}
+
+		if input.AddClickable.Hovered() || input.CopyLinkClickable.Hovered() {
+			pointer.CursorPointer.Add(gtx.Ops)
 		}
 	}
 
-	if state.TwitchChannelClickable.Clicked(gtx) && validateTwichChannelURLEditor(state) {
-		if state.TwitchChannelSet == "" {
-			state.TwitchChannelSet = state.TwitchChannelURLEditor.Text()
-			uiEvents <- UIEventSetTwitchChannel{
-				Channel: state.TwitchChannelURLEditor.Text(),
+	for _, channel := range state.GetChannels() {
+		if channel.RemoveClickable.Clicked(gtx) {
+			state.RemoveChannel(channel)
+			uiEvents <- UIEventRemoveChannel{
+				Platform: channel.Platform,
+				Channel:  channel.Channel,
 			}
-		} else {
-			state.TwitchChannelWasConnected = false
-			state.TwitchChannelSet = ""
-			uiEvents <- UIEventRemoveTwitchChannel{}
+		}
+		if channel.RemoveClickable.Hovered() {
+			pointer.CursorPointer.Add(gtx.Ops)
 		}
 	}
 
 	if state.CopyLinkToChatClickable.Clicked(gtx) {
-		gtx.Execute(clipboard.WriteCmd{Data: io.NopCloser(strings.NewReader("http://localhost:1337"))})
+		gtx.Execute(clipboard.WriteCmd{Data: io.NopCloser(strings.NewReader(state.OverlayURL))})
 		state.CopyLinkToChatCopied = true
 		go func() {
 			time.Sleep(time.Second * 2)
@@ -244,22 +233,32 @@ func emitEvents(gtx layC, state *UIState, uiEvents chan<- UIEvent) {
 		}()
 	}
 
-	if state.CopyLinkToYTClickable.Clicked(gtx) {
-		gtx.Execute(clipboard.WriteCmd{Data: io.NopCloser(strings.NewReader("http://localhost:1337?platform=youtube"))})
-		state.CopyLinkToYTClicked = true
-		go func() {
-			time.Sleep(time.Second * 2)
-			state.CopyLinkToYTClicked = false
-		}()
+	if state.RotateTokensClickable.Clicked(gtx) {
+		if state.RotateTokensConfirming {
+			state.RotateTokensConfirming = false
+			uiEvents <- UIEventRotateTokens{}
+		} else {
+			state.RotateTokensConfirming = true
+			go func() {
+				time.Sleep(time.Second * 3)
+				state.RotateTokensConfirming = false
+			}()
+		}
 	}
 
-	if state.CopyLinkToTwClickable.Clicked(gtx) {
-		gtx.Execute(clipboard.WriteCmd{Data: io.NopCloser(strings.NewReader("http://localhost:1337?platform=twitch"))})
-		state.CopyLinkToTwClicked = true
-		go func() {
-			time.Sleep(time.Second * 2)
-			state.CopyLinkToTwClicked = false
-		}()
+	if state.RecordChatBool.Update(gtx) {
+		uiEvents <- UIEventSetRecordChat{
+			Enabled: state.RecordChatBool.Value,
+		}
+	}
+
+	simulatorToggled := state.SimulatorBool.Update(gtx)
+	rateChanged := state.SimulatorRateFloat.Update(gtx) && state.SimulatorBool.Value
+	if simulatorToggled || rateChanged {
+		uiEvents <- UIEventSetSimulator{
+			Enabled:           state.SimulatorBool.Value,
+			MessagesPerSecond: state.GetSimulatorRate(),
+		}
 	}
 
 	if state.ConfirmCSSClickable.Clicked(gtx) {
@@ -277,14 +276,11 @@ func emitEvents(gtx layC, state *UIState, uiEvents chan<- UIEvent) {
 	}
 
 	if state.VersionClickable.Clicked(gtx) {
-		windows.ShellExecute(0, nil, windows.StringToUTF16Ptr("https://github.com/MatheusAlvesA/OverTube"), nil, nil, windows.SW_SHOWNORMAL)
+		openURL("https://github.com/MatheusAlvesA/OverTube")
 	}
 
-	if state.YouTubeChannelClickable.Hovered() ||
-		state.TwitchChannelClickable.Hovered() ||
-		state.CopyLinkToChatClickable.Hovered() ||
-		state.CopyLinkToYTClickable.Hovered() ||
-		state.CopyLinkToTwClickable.Hovered() ||
+	if state.CopyLinkToChatClickable.Hovered() ||
+		state.RotateTokensClickable.Hovered() ||
 		state.VersionClickable.Hovered() {
 		pointer.CursorPointer.Add(gtx.Ops)
 	}
@@ -322,37 +318,64 @@ func renderTitle(gtx layC, theme *material.Theme, state *UIState) layD {
 		}
 		labelVersion.Alignment = text.End
 
-		return layout.Flex{
-			Axis:      layout.Horizontal,
-			Spacing:   layout.SpaceBetween,
-			Alignment: layout.Middle,
-		}.Layout(gtx, layout.Rigid(func(gtx layC) layD {
-			return title.Layout(gtx)
-		}), layout.Rigid(func(gtx layC) layD {
-			return state.VersionClickable.Layout(gtx, func(gtx layC) layD {
-				return labelVersion.Layout(gtx)
-			})
-		}))
+		titleRow := layout.Rigid(func(gtx layC) layD {
+			return layout.Flex{
+				Axis:      layout.Horizontal,
+				Spacing:   layout.SpaceBetween,
+				Alignment: layout.Middle,
+			}.Layout(gtx, layout.Rigid(func(gtx layC) layD {
+				return title.Layout(gtx)
+			}), layout.Rigid(func(gtx layC) layD {
+				return state.VersionClickable.Layout(gtx, func(gtx layC) layD {
+					return labelVersion.Layout(gtx)
+				})
+			}))
+		})
+		rows := []layout.FlexChild{titleRow}
+		for _, message := range state.GetServerErrors() {
+			rows = append(rows, layout.Rigid(func(gtx layC) layD {
+				label := material.Body2(theme, message)
+				label.Color = color.NRGBA{R: 200, G: 0, B: 0, A: 255}
+				return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, label.Layout)
+			}))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
 	})
 }
 
-func renderYoutubeChannelInput(
+func renderPlatformSection(
 	gtx layC,
 	theme *material.Theme,
 	state *UIState,
+	input *PlatformInputState,
 ) layD {
-	editor := state.YoutubeChannelURLEditor
-	editorUI := material.Editor(theme, editor, "YouTube @Channel")
-	editorUI.LineHeight = 1.5
+	channels := state.GetChannelsFromPlatform(input.Platform)
+	rows := []layout.FlexChild{
+		layout.Rigid(func(gtx layC) layD {
+			return renderChannelInput(gtx, theme, input)
+		}),
+	}
+	for _, channel := range channels {
+		rows = append(rows, layout.Rigid(func(gtx layC) layD {
+			return renderChannelRow(gtx, theme, channel)
+		}))
+	}
 
-	submit := state.YouTubeChannelClickable
-	submitUI := material.Button(theme, submit, "Definir")
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
+}
 
-	if state.YoutubeChannelSet != "" {
-		submitUI.Text = "Remover"
-	}
+func renderChannelInput(
+	gtx layC,
+	theme *material.Theme,
+	input *PlatformInputState,
+) layD {
+	editorUI := material.Editor(theme, input.ChannelEditor, input.Placeholder)
+	editorUI.LineHeight = 1.5
+	labelEditorUI := material.Editor(theme, input.LabelEditor, "Rótulo (opcional)")
+	labelEditorUI.LineHeight = 1.5
 
-	if state.YoutubeChannelURLEditor.Text() == "" {
+	submitUI := material.Button(theme, input.AddClickable, "Adicionar")
+	if input.ChannelEditor.Text() == "" {
 		submitUI.Background = color.NRGBA{R: 200, G: 200, B: 200, A: 255}
 		submitUI.Color = color.NRGBA{R: 100, G: 100, B: 100, A: 255}
 	}
@@ -361,7 +384,7 @@ func renderYoutubeChannelInput(
 		Top:    unit.Dp(16),
 		Left:   unit.Dp(16),
 		Right:  unit.Dp(16),
-		Bottom: unit.Dp(16),
+		Bottom: unit.Dp(8),
 	}
 
 	return margin.Layout(gtx, func(gtx layC) layD {
@@ -374,80 +397,27 @@ func renderYoutubeChannelInput(
 			layout.Rigid(
 				func(gtx layC) layD {
 					return layout.Inset{Right: unit.Dp(10)}.Layout(gtx, func(gtx layC) layD {
-						// Image of youtube logo
-						file, err := platformIcons.Open("platform_icons/yt.png")
-						if err != nil {
-							log.Println("Error loading youtube logo:", err)
-							// Fallback: create a red square if image loading fails
-							img := image.NewRGBA(image.Rect(0, 0, 25, 25))
-							draw.Draw(img, img.Bounds(), &image.Uniform{C: color.NRGBA{R: 255, G: 0, B: 0, A: 255}}, image.Point{}, draw.Src)
-							return widget.Image{Src: paint.NewImageOp(img)}.Layout(gtx)
-						}
-						defer file.Close()
-
-						img, err := png.Decode(file)
-						if err != nil {
-							log.Println("Error decoding youtube logo:", err)
-							// Fallback: create a red square if image decoding fails
-							fallbackImg := image.NewRGBA(image.Rect(0, 0, 25, 25))
-							draw.Draw(fallbackImg, fallbackImg.Bounds(), &image.Uniform{C: color.NRGBA{R: 255, G: 0, B: 0, A: 255}}, image.Point{}, draw.Src)
-							return widget.Image{Src: paint.NewImageOp(fallbackImg)}.Layout(gtx)
-						}
-
-						return widget.Image{
-							Src:   paint.NewImageOp(img),
-							Scale: 0.6,
-						}.Layout(gtx)
+						return renderPlatformIcon(gtx, input.IconPath, input.IconScale)
 					})
 				},
 			),
 			layout.Flexed(
 				1,
 				func(gtx layC) layD {
-					return widget.Border{
-						Color:        color.NRGBA{R: 200, G: 200, B: 200, A: 255},
-						Width:        unit.Dp(1),
-						CornerRadius: unit.Dp(4),
-					}.Layout(gtx, func(gtx layC) layD {
-						return layout.UniformInset(6).Layout(
-							gtx,
-							func(gtx layC) layD {
-								if state.YoutubeChannelSet != "" {
-									paint.FillShape(gtx.Ops,
-										color.NRGBA{R: 220, G: 220, B: 220, A: 255},
-										clip.Rect{
-											Max: image.Point{
-												X: gtx.Constraints.Max.X,
-												Y: gtx.Dp(unit.Dp(20)),
-											},
-										}.Op(),
-									)
-									return material.Body1(theme, state.YoutubeChannelSet).Layout(gtx)
-								}
-								return editorUI.Layout(gtx)
-							},
-						)
+					return renderEditorBorder(gtx, func(gtx layC) layD {
+						return editorUI.Layout(gtx)
 					})
 				},
 			),
 			layout.Rigid(
 				func(gtx layC) layD {
-					circle := clip.Ellipse{
-						Min: image.Pt(10, 10),
-						Max: image.Pt(20, 20),
-					}.Op(gtx.Ops)
-
-					c := color.NRGBA{R: 92, G: 184, B: 92, A: 255}
-					if state.YoutubeConnStatus == ws_server.ChannelConnectionStarting {
-						c = color.NRGBA{R: 255, G: 204, B: 0, A: 255}
-					}
-					if state.YoutubeConnStatus == ws_server.ChannelConnectionStopped {
-						c = color.NRGBA{R: 204, G: 51, B: 0, A: 255}
-					}
-
-					paint.FillShape(gtx.Ops, c, circle)
-
-					return layout.Dimensions{Size: image.Pt(25, 25)}
+					return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layC) layD {
+						gtx.Constraints.Min.X = gtx.Dp(unit.Dp(150))
+						gtx.Constraints.Max.X = gtx.Dp(unit.Dp(150))
+						return renderEditorBorder(gtx, func(gtx layC) layD {
+							return labelEditorUI.Layout(gtx)
+						})
+					})
 				},
 			),
 			layout.Rigid(
@@ -464,35 +434,32 @@ func renderYoutubeChannelInput(
 	})
 }
 
-func renderTwichChannelInput(
+func renderChannelRow(
 	gtx layC,
 	theme *material.Theme,
-	state *UIState,
+	channel *ChannelState,
 ) layD {
-	editor := state.TwitchChannelURLEditor
-	editorUI := material.Editor(theme, editor, "Twitch username")
-	editorUI.LineHeight = 1.5
-
-	submit := state.TwitchChannelClickable
-	submitUI := material.Button(theme, submit, "Definir")
-
-	if state.TwitchChannelSet != "" {
-		submitUI.Text = "Remover"
+	text := channel.Channel
+	if channel.Label != "" {
+		text += " (" + channel.Label + ")"
 	}
-
-	if editor.Text() == "" {
-		submitUI.Background = color.NRGBA{R: 200, G: 200, B: 200, A: 255}
-		submitUI.Color = color.NRGBA{R: 100, G: 100, B: 100, A: 255}
+	if channel.ConnStatus == ws_server.ChannelConnectionWaiting {
+		text += " - aguardando a live começar"
 	}
-
-	margin := layout.Inset{
-		Top:    unit.Dp(16),
-		Left:   unit.Dp(16),
-		Right:  unit.Dp(16),
-		Bottom: unit.Dp(16),
+	if channel.ConnStatus == ws_server.ChannelConnectionRunning && channel.VideoId != "" {
+		text += " - vídeo " + channel.VideoId
 	}
 
-	return margin.Layout(gtx, func(gtx layC) layD {
+	removeUI := material.Button(theme, channel.RemoveClickable, "Remover")
+	removeUI.Background = color.NRGBA{R: 255, G: 165, B: 100, A: 255}
+	removeUI.Color = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+	return layout.Inset{
+		Top:    unit.Dp(4),
+		Left:   unit.Dp(56),
+		Right:  unit.Dp(16),
+		Bottom: unit.Dp(4),
+	}.Layout(gtx, func(gtx layC) layD {
 		return layout.Flex{
 			Axis:      layout.Horizontal,
 			Spacing:   layout.SpaceBetween,
@@ -501,115 +468,122 @@ func renderTwichChannelInput(
 			gtx,
 			layout.Rigid(
 				func(gtx layC) layD {
-					return layout.Inset{Right: unit.Dp(10)}.Layout(gtx, func(gtx layC) layD {
-						// Image of twitch logo
-						file, err := platformIcons.Open("platform_icons/tw.png")
-						if err != nil {
-							log.Println("Error loading twitch logo:", err)
-							// Fallback: create a red square if image loading fails
-							img := image.NewRGBA(image.Rect(0, 0, 25, 25))
-							draw.Draw(img, img.Bounds(), &image.Uniform{C: color.NRGBA{R: 255, G: 0, B: 0, A: 255}}, image.Point{}, draw.Src)
-							return widget.Image{Src: paint.NewImageOp(img)}.Layout(gtx)
-						}
-						defer file.Close()
-
-						img, err := png.Decode(file)
-						if err != nil {
-							log.Println("Error decoding twitch logo:", err)
-							// Fallback: create a red square if image decoding fails
-							fallbackImg := image.NewRGBA(image.Rect(0, 0, 25, 25))
-							draw.Draw(fallbackImg, fallbackImg.Bounds(), &image.Uniform{C: color.NRGBA{R: 255, G: 0, B: 0, A: 255}}, image.Point{}, draw.Src)
-							return widget.Image{Src: paint.NewImageOp(fallbackImg)}.Layout(gtx)
-						}
-
-						return widget.Image{
-							Src:   paint.NewImageOp(img),
-							Scale: 0.45,
-						}.Layout(gtx)
-					})
+					return renderStatusCircle(gtx, channel.ConnStatus)
 				},
 			),
 			layout.Flexed(
 				1,
 				func(gtx layC) layD {
-					return widget.Border{
-						Color:        color.NRGBA{R: 200, G: 200, B: 200, A: 255},
-						Width:        unit.Dp(1),
-						CornerRadius: unit.Dp(4),
-					}.Layout(gtx, func(gtx layC) layD {
-						return layout.UniformInset(6).Layout(
-							gtx,
-							func(gtx layC) layD {
-								if state.TwitchChannelSet != "" {
-									paint.FillShape(gtx.Ops,
-										color.NRGBA{R: 220, G: 220, B: 220, A: 255},
-										clip.Rect{
-											Max: image.Point{
-												X: gtx.Constraints.Max.X,
-												Y: gtx.Dp(unit.Dp(20)),
-											},
-										}.Op(),
-									)
-									return material.Body1(theme, state.TwitchChannelSet).Layout(gtx)
-								}
-								return editorUI.Layout(gtx)
-							},
-						)
+					return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layC) layD {
+						return material.Body1(theme, text).Layout(gtx)
 					})
 				},
 			),
 			layout.Rigid(
 				func(gtx layC) layD {
-					circle := clip.Ellipse{
-						Min: image.Pt(10, 10),
-						Max: image.Pt(20, 20),
-					}.Op(gtx.Ops)
-
-					c := color.NRGBA{R: 92, G: 184, B: 92, A: 255}
-					if state.TwitchConnStatus == ws_server.ChannelConnectionStarting {
-						c = color.NRGBA{R: 255, G: 204, B: 0, A: 255}
-					}
-					if state.TwitchConnStatus == ws_server.ChannelConnectionStopped {
-						c = color.NRGBA{R: 204, G: 51, B: 0, A: 255}
-					}
-
-					paint.FillShape(gtx.Ops, c, circle)
-
-					return layout.Dimensions{Size: image.Pt(25, 25)}
-				},
-			),
-			layout.Rigid(
-				func(gtx layC) layD {
-					return layout.Inset{Left: unit.Dp(8)}.Layout(
-						gtx,
-						func(gtx layC) layD {
-							return submitUI.Layout(gtx)
-						},
-					)
+					return removeUI.Layout(gtx)
 				},
 			),
 		)
 	})
 }
 
+func renderEditorBorder(gtx layC, content layout.Widget) layD {
+	return widget.Border{
+		Color:        color.NRGBA{R: 200, G: 200, B: 200, A: 255},
+		Width:        unit.Dp(1),
+		CornerRadius: unit.Dp(4),
+	}.Layout(gtx, func(gtx layC) layD {
+		return layout.UniformInset(6).Layout(gtx, content)
+	})
+}
+
+func renderPlatformIcon(gtx layC, iconPath string, scale float32) layD {
+	file, err := platformIcons.Open(iconPath)
+	if err != nil {
+		log.Println("Error loading platform logo:", iconPath, err)
+		// Fallback: create a red square if image loading fails
+		img := image.NewRGBA(image.Rect(0, 0, 25, 25))
+		draw.Draw(img, img.Bounds(), &image.Uniform{C: color.NRGBA{R: 255, G: 0, B: 0, A: 255}}, image.Point{}, draw.Src)
+		return widget.Image{Src: paint.NewImageOp(img)}.Layout(gtx)
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		log.Println("Error decoding platform logo:", iconPath, err)
+		// Fallback: create a red square if image decoding fails
+		fallbackImg := image.NewRGBA(image.Rect(0, 0, 25, 25))
+		draw.Draw(fallbackImg, fallbackImg.Bounds(), &image.Uniform{C: color.NRGBA{R: 255, G: 0, B: 0, A: 255}}, image.Point{}, draw.Src)
+		return widget.Image{Src: paint.NewImageOp(fallbackImg)}.Layout(gtx)
+	}
+
+	return widget.Image{
+		Src:   paint.NewImageOp(img),
+		Scale: scale,
+	}.Layout(gtx)
+}
+
+func renderStatusCircle(gtx layC, status ws_server.ChannelConnectionStatus) layD {
+	circle := clip.Ellipse{
+		Min: image.Pt(10, 10),
+		Max: image.Pt(20, 20),
+	}.Op(gtx.Ops)
+
+	c := color.NRGBA{R: 92, G: 184, B: 92, A: 255}
+	if status == ws_server.ChannelConnectionStarting {
+		c = color.NRGBA{R: 255, G: 204, B: 0, A: 255}
+	}
+	if status == ws_server.ChannelConnectionStopped {
+		c = color.NRGBA{R: 204, G: 51, B: 0, A: 255}
+	}
+	if status == ws_server.ChannelConnectionWaiting {
+		c = color.NRGBA{R: 66, G: 139, B: 202, A: 255}
+	}
+
+	paint.FillShape(gtx.Ops, c, circle)
+
+	return layout.Dimensions{Size: image.Pt(25, 25)}
+}
+
 func renderBtnCopyLinkToChat(
 	gtx layC,
 	theme *material.Theme,
 	state *UIState,
 ) layD {
 	btnUI := material.Button(theme, state.CopyLinkToChatClickable, "Copiar link para o chat (Combinado)")
-	btnUIYT := material.Button(theme, state.CopyLinkToYTClickable, "Copiar link para o chat (YouTube)")
-	btnUITW := material.Button(theme, state.CopyLinkToTwClickable, "Copiar link para o chat (Twitch)")
-
 	if state.CopyLinkToChatCopied {
 		btnUI.Text = "Copiado!"
 	}
-	if state.CopyLinkToYTClicked {
-		btnUIYT.Text = "Copiado!"
+
+	buttons := []layout.Widget{
+		func(gtx layC) layD {
+			gtx.Constraints.Min.X = gtx.Dp(unit.Dp(200))
+			gtx.Constraints.Max.X = gtx.Dp(unit.Dp(200))
+			return btnUI.Layout(gtx)
+		},
+	}
+	for _, input := range state.PlatformInputs {
+		platformBtnUI := material.Button(theme, input.CopyLinkClickable, input.CopyLinkLabel)
+		if input.CopyLinkClicked {
+			platformBtnUI.Text = "Copiado!"
+		}
+		buttons = append(buttons, func(gtx layC) layD {
+			gtx.Constraints.Min.X = gtx.Dp(unit.Dp(200))
+			gtx.Constraints.Max.X = gtx.Dp(unit.Dp(200))
+			return platformBtnUI.Layout(gtx)
+		})
 	}
-	if state.CopyLinkToTwClicked {
-		btnUITW.Text = "Copiado!"
+	rotateBtnUI := material.Button(theme, state.RotateTokensClickable, "Gerar novos links")
+	rotateBtnUI.Background = color.NRGBA{R: 127, G: 0, B: 0, A: 255}
+	if state.RotateTokensConfirming {
+		rotateBtnUI.Text = "Invalidar links antigos?"
 	}
+	buttons = append(buttons, func(gtx layC) layD {
+		gtx.Constraints.Min.X = gtx.Dp(unit.Dp(200))
+		gtx.Constraints.Max.X = gtx.Dp(unit.Dp(200))
+		return rotateBtnUI.Layout(gtx)
+	})
 
 	return layout.Inset{
 		Top:    unit.Dp(16),
@@ -617,23 +591,58 @@ func renderBtnCopyLinkToChat(
 		Right:  unit.Dp(16),
 		Bottom: unit.Dp(16),
 	}.Layout(gtx, func(gtx layC) layD {
-		return Flow{Spacing: unit.Dp(8)}.Layout(
+		return Flow{Spacing: unit.Dp(8)}.Layout(gtx, buttons...)
+	})
+}
+
+func renderRecordChatCheckbox(
+	gtx layC,
+	theme *material.Theme,
+	state *UIState,
+) layD {
+	checkbox := material.CheckBox(theme, state.RecordChatBool, "Gravar chat em arquivo (pasta recordings)")
+
+	return layout.Inset{
+		Top:    unit.Dp(0),
+		Left:   unit.Dp(16),
+		Right:  unit.Dp(16),
+		Bottom: unit.Dp(16),
+	}.Layout(gtx, func(gtx layC) layD {
+		return checkbox.Layout(gtx)
+	})
+}
+
+func renderSimulatorSection(
+	gtx layC,
+	theme *material.Theme,
+	state *UIState,
+) layD {
+	checkbox := material.CheckBox(theme, state.SimulatorBool, "Simular chat (pré-visualização sem live)")
+	slider := material.Slider(theme, state.SimulatorRateFloat)
+	rateLabel := material.Body2(theme, fmt.Sprintf("%.1f msg/s", state.GetSimulatorRate()))
+
+	return layout.Inset{
+		Top:    unit.Dp(0),
+		Left:   unit.Dp(16),
+		Right:  unit.Dp(16),
+		Bottom: unit.Dp(16),
+	}.Layout(gtx, func(gtx layC) layD {
+		return layout.Flex{
+			Axis:      layout.Horizontal,
+			Alignment: layout.Middle,
+		}.Layout(
 			gtx,
-			func(gtx layC) layD {
-				gtx.Constraints.Min.X = gtx.Dp(unit.Dp(200))
-				gtx.Constraints.Max.X = gtx.Dp(unit.Dp(200))
-				return btnUI.Layout(gtx)
-			},
-			func(gtx layC) layD {
-				gtx.Constraints.Min.X = gtx.Dp(unit.Dp(200))
-				gtx.Constraints.Max.X = gtx.Dp(unit.Dp(200))
-				return btnUIYT.Layout(gtx)
-			},
-			func(gtx layC) layD {
-				gtx.Constraints.Min.X = gtx.Dp(unit.Dp(200))
-				gtx.Constraints.Max.X = gtx.Dp(unit.Dp(200))
-				return btnUITW.Layout(gtx)
-			},
+			layout.Rigid(func(gtx layC) layD {
+				return checkbox.Layout(gtx)
+			}),
+			layout.Flexed(1, func(gtx layC) layD {
+				return layout.Inset{Left: unit.Dp(16), Right: unit.Dp(8)}.Layout(gtx, func(gtx layC) layD {
+					return slider.Layout(gtx)
+				})
+			}),
+			layout.Rigid(func(gtx layC) layD {
+				return rateLabel.Layout(gtx)
+			}),
 		)
 	})
 }
@@ -679,20 +688,10 @@ func renderCustomSectionLineSeparator(gtx layC, theme *material.Theme) layD {
 	)
 }
 
-func validateTwichChannelURLEditor(state *UIState) bool {
-	re := regexp.MustCompile(`[^a-zA-Z0-9_]`)
-	currentText := state.TwitchChannelURLEditor.Text()
-	cleanedText := re.ReplaceAllString(currentText, "")
-	state.TwitchChannelURLEditor.SetText(cleanedText)
-
-	return cleanedText != ""
-}
-
-func validateYoutubeChannelURLEditor(state *UIState) bool {
-	re := regexp.MustCompile(`[^a-zA-Z0-9_-]`)
-	currentText := state.YoutubeChannelURLEditor.Text()
-	cleanedText := re.ReplaceAllString(currentText, "")
-	state.YoutubeChannelURLEditor.SetText(cleanedText)
+func validateChannelEditor(input *PlatformInputState) bool {
+	currentText := input.ChannelEditor.Text()
+	cleanedText := input.InvalidChars.ReplaceAllString(currentText, "")
+	input.ChannelEditor.SetText(cleanedText)
 
 	return cleanedText != ""
 }
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package ui
+
+import (
+	"log"
+	"os/exec"
+	"runtime"
+)
+
+func openURL(url string) {
+	command := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		command = "open"
+	}
+	err := exec.Command(command, url).Start()
+	if err != nil {
+		log.Println("[ui] Fail to open URL", url, err)
+	}
+}
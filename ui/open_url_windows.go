@@ -0,0 +1,9 @@
+//go:build windows
+
+package ui
+
+import "golang.org/x/sys/windows"
+
+func openURL(url string) {
+	windows.ShellExecute(0, nil, windows.StringToUTF16Ptr(url), nil, nil, windows.SW_SHOWNORMAL)
+}
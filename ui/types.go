@@ -4,6 +4,8 @@ import (
 	"image"
 	"overtube/chat_stream"
 	"overtube/ws_server"
+	"regexp"
+	"sync"
 
 	"gioui.org/layout"
 	"gioui.org/op"
@@ -24,6 +26,7 @@ type UICommand interface {
 
 type ChannelConnectionStatusChange struct {
 	Platform chat_stream.PlatformType
+	Channel  string
 	Status   ws_server.ChannelConnectionStatus
 }
 
@@ -31,29 +34,109 @@ func (c ChannelConnectionStatusChange) GetData() any {
 	return c
 }
 
+type ChannelVideoChange struct {
+	Platform chat_stream.PlatformType
+	Channel  string
+	VideoId  string
+}
+
+func (c ChannelVideoChange) GetData() any {
+	return c
+}
+
+// ServerError avisa que um dos servidores locais não conseguiu iniciar, ex: porta já em uso
+type ServerError struct {
+	Message string
+}
+
+func (c ServerError) GetData() any {
+	return c
+}
+
+// OverlayURLChange avisa que o link do overlay mudou, ex: tokens trocados
+type OverlayURLChange struct {
+	URL string
+}
+
+func (c OverlayURLChange) GetData() any {
+	return c
+}
+
+// ChannelAdded e as outras mudanças abaixo vêm da API de controle, a janela só atualiza o que mostra
+type ChannelAdded struct {
+	Platform chat_stream.PlatformType
+	Channel  string
+	Label    string
+}
+
+func (c ChannelAdded) GetData() any {
+	return c
+}
+
+type ChannelRemoved struct {
+	Platform chat_stream.PlatformType
+	Channel  string
+}
+
+func (c ChannelRemoved) GetData() any {
+	return c
+}
+
+type ChatStyleChange struct {
+	Id uint
+}
+
+func (c ChatStyleChange) GetData() any {
+	return c
+}
+
+type ChatStyleCSSChange struct {
+	Id  uint
+	CSS string
+}
+
+func (c ChatStyleCSSChange) GetData() any {
+	return c
+}
+
 type UIEventExit struct {
 	err error
 }
 
 func (e UIEventExit) GetError() error { return e.err }
 
-type UIEventSetYoutubeChannel struct {
-	Channel string
+type UIEventAddChannel struct {
+	Platform chat_stream.PlatformType
+	Channel  string
+	Label    string
 }
 
-type UIEventSetTwitchChannel struct {
-	Channel string
+func (e UIEventAddChannel) GetError() error { return nil }
+
+type UIEventRemoveChannel struct {
+	Platform chat_stream.PlatformType
+	Channel  string
 }
 
-func (e UIEventSetYoutubeChannel) GetError() error { return nil }
+func (e UIEventRemoveChannel) GetError() error { return nil }
 
-func (e UIEventSetTwitchChannel) GetError() error { return nil }
+type UIEventSetRecordChat struct {
+	Enabled bool
+}
 
-type UIEventRemoveYoutubeChannel struct{}
-type UIEventRemoveTwitchChannel struct{}
+func (e UIEventSetRecordChat) GetError() error { return nil }
+
+type UIEventSetSimulator struct {
+	Enabled           bool
+	MessagesPerSecond float64
+}
 
-func (e UIEventRemoveYoutubeChannel) GetError() error { return nil }
-func (e UIEventRemoveTwitchChannel) GetError() error  { return nil }
+func (e UIEventSetSimulator) GetError() error { return nil }
+
+// UIEventRotateTokens pede novos tokens, os links copiados antes deixam de funcionar
+type UIEventRotateTokens struct{}
+
+func (e UIEventRotateTokens) GetError() error { return nil }
 
 type UIEventSetChatStyle struct {
 	Id uint
@@ -74,27 +157,49 @@ type ResetChatStyleCustomCSS struct {
 
 func (e ResetChatStyleCustomCSS) GetError() error { return nil }
 
+// PlatformInputState guarda os campos para adicionar canais de uma plataforma
+type PlatformInputState struct {
+	Platform          chat_stream.PlatformType
+	Placeholder       string
+	IconPath          string
+	IconScale         float32
+	InvalidChars      *regexp.Regexp
+	Normalize         func(string) string // Converte a entrada no identificador salvo, opcional
+	ChannelEditor     *widget.Editor
+	LabelEditor       *widget.Editor
+	AddClickable      *widget.Clickable
+	CopyLinkLabel     string
+	CopyLinkClickable *widget.Clickable
+	CopyLinkClicked   bool
+}
+
+// ChannelState representa um canal conectado (ou tentando conectar) e o seu status
+type ChannelState struct {
+	Platform        chat_stream.PlatformType
+	Channel         string
+	Label           string
+	ConnStatus      ws_server.ChannelConnectionStatus
+	VideoId         string // Vídeo ao qual o chat foi conectado (apenas YouTube)
+	RemoveClickable *widget.Clickable
+}
+
 type UIState struct {
-	YoutubeChannelURLEditor    *widget.Editor
-	YouTubeChannelClickable    *widget.Clickable
-	YoutubeChannelSet          string
-	YoutubeConnStatus          ws_server.ChannelConnectionStatus
-	YoutubeChannelWasConnected bool
-
-	TwitchChannelURLEditor    *widget.Editor
-	TwitchChannelClickable    *widget.Clickable
-	TwitchChannelSet          string
-	TwitchConnStatus          ws_server.ChannelConnectionStatus
-	TwitchChannelWasConnected bool
+	PlatformInputs []*PlatformInputState
+	Channels       []*ChannelState
+	channelsMu     sync.Mutex
 
 	CopyLinkToChatClickable *widget.Clickable
 	CopyLinkToChatCopied    bool
-	CopyLinkToYTClickable   *widget.Clickable
-	CopyLinkToTwClickable   *widget.Clickable
-	CopyLinkToYTClicked     bool
-	CopyLinkToTwClicked     bool
+	RotateTokensClickable   *widget.Clickable
+	RotateTokensConfirming  bool // Primeiro clique só pede confirmação, os links no OBS param de funcionar
+	RecordChatBool          *widget.Bool
+	SimulatorBool           *widget.Bool
+	SimulatorRateFloat      *widget.Float
 
 	VersionClickable *widget.Clickable
+	OverlayURL       string
+	ServerErrors     []string
+	serverErrorsMu   sync.Mutex
 
 	ChatStyleId         uint
 	ChatStyleClickables map[uint]*widget.Clickable
@@ -103,8 +208,68 @@ type UIState struct {
 	RevertCSSClickable  *widget.Clickable
 
 	MainList *widget.List
+}
+
+// GetSimulatorRate converte a posição do slider (0 a 1) em mensagens por segundo
+func (s *UIState) GetSimulatorRate() float64 {
+	minRate := float64(chat_stream.SimulatorMinMessagesPerSecond)
+	maxRate := float64(chat_stream.SimulatorMaxMessagesPerSecond)
+	return minRate + float64(s.SimulatorRateFloat.Value)*(maxRate-minRate)
+}
 
-	UIClosed bool
+func (s *UIState) AddServerError(message string) {
+	s.serverErrorsMu.Lock()
+	defer s.serverErrorsMu.Unlock()
+	s.ServerErrors = append(s.ServerErrors, message)
+}
+
+func (s *UIState) GetServerErrors() []string {
+	s.serverErrorsMu.Lock()
+	defer s.serverErrorsMu.Unlock()
+	return append([]string{}, s.ServerErrors...)
+}
+
+func (s *UIState) GetChannels() []*ChannelState {
+	s.channelsMu.Lock()
+	defer s.channelsMu.Unlock()
+	return append([]*ChannelState{}, s.Channels...)
+}
+
+func (s *UIState) GetChannelsFromPlatform(platform chat_stream.PlatformType) []*ChannelState {
+	list := []*ChannelState{}
+	for _, channel := range s.GetChannels() {
+		if channel.Platform == platform {
+			list = append(list, channel)
+		}
+	}
+	return list
+}
+
+func (s *UIState) FindChannel(platform chat_stream.PlatformType, channel string) *ChannelState {
+	for _, entry := range s.GetChannels() {
+		if entry.Platform == platform && entry.Channel == channel {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *UIState) AddChannel(channel *ChannelState) {
+	s.channelsMu.Lock()
+	defer s.channelsMu.Unlock()
+	s.Channels = append(s.Channels, channel)
+}
+
+func (s *UIState) RemoveChannel(channel *ChannelState) {
+	s.channelsMu.Lock()
+	defer s.channelsMu.Unlock()
+	filtered := []*ChannelState{}
+	for _, entry := range s.Channels {
+		if entry != channel {
+			filtered = append(filtered, entry)
+		}
+	}
+	s.Channels = filtered
 }
 
 func (s *UIState) GetChatStyleClickable(id uint) *widget.Clickable {
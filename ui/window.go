@@ -0,0 +1,86 @@
+//go:build !headless
+
+package ui
+
+import (
+	"log"
+	"overtube/save_state"
+
+	"gioui.org/app"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget/material"
+)
+
+func CreateHomeWindow(uiEvents chan<- UIEvent, uiCommands <-chan UICommand, appState *save_state.AppState) {
+	go func() {
+		window := &app.Window{}
+		window.Option(app.Title("OverTube"))
+		window.Option(app.MinSize(400, 300))
+		window.Option(app.Size(800, 660))
+		err := run(window, uiEvents, uiCommands, appState)
+		uiEvents <- UIEventExit{err: err}
+		close(uiEvents)
+	}()
+	app.Main()
+}
+
+func run(window *app.Window, uiEvents chan<- UIEvent, uiCommands <-chan UICommand, appState *save_state.AppState) error {
+	theme := material.NewTheme()
+	state := initialState()
+	readAppState(state, *appState)
+	go listenToCommands(window, state, uiCommands)
+	var ops op.Ops
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return e.Err
+		case app.FrameEvent:
+			gtx := app.NewContext(&ops, e)
+
+			emitEvents(gtx, state, uiEvents)
+
+			// Main component layout
+			nPlatforms := len(state.PlatformInputs)
+			state.MainList.Layout(gtx, nPlatforms+8, func(gtx layC, index int) layD {
+				if index == 0 {
+					return renderTitle(gtx, theme, state)
+				}
+				if index <= nPlatforms {
+					return renderPlatformSection(gtx, theme, state, state.PlatformInputs[index-1])
+				}
+				switch index - nPlatforms {
+				case 1:
+					return renderBtnCopyLinkToChat(gtx, theme, state)
+				case 2:
+					return renderRecordChatCheckbox(gtx, theme, state)
+				case 3:
+					return renderSimulatorSection(gtx, theme, state)
+				case 4:
+					return renderCustomSectionLineSeparator(gtx, theme)
+				case 5:
+					return renderCustomizeSection(gtx, theme, state)
+				case 6:
+					return renderCSSInputSection(gtx, theme, state)
+				case 7:
+					return renderCSSInputConfirmBtns(gtx, theme, state)
+				default:
+					return layout.Dimensions{}
+				}
+			})
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+func listenToCommands(w *app.Window, state *UIState, uiCommands <-chan UICommand) {
+	for {
+		cmd, more := <-uiCommands
+		if !more {
+			log.Println("uiCommands event channel closed")
+			break
+		}
+		handleCommand(w, state, cmd)
+	}
+}
@@ -0,0 +1,66 @@
+package web_server
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"overtube/save_state"
+	"strings"
+)
+
+// SetTokens troca os tokens aceitos, vale para as próximas requisições
+func (s *WebChatStreamServer) SetTokens(overlayToken string, controlToken string) {
+	s.tokensMu.Lock()
+	defer s.tokensMu.Unlock()
+	s.overlayToken = overlayToken
+	s.controlToken = controlToken
+}
+
+// HandleOverlay monta uma rota que entrega o chat, o token do overlay só é exigido quando o servidor
+// está aberto para a rede, assim os links antigos do OBS deste PC continuam funcionando
+func (s *WebChatStreamServer) HandleOverlay(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := getRequestToken(r)
+		s.tokensMu.RLock()
+		allowed := save_state.IsLoopbackAddress(s.BindAddress) || tokenMatches(token, s.overlayToken) || tokenMatches(token, s.controlToken)
+		s.tokensMu.RUnlock()
+		if !allowed {
+			log.Println("[WebChatStreamServer] Denying", r.URL.Path, "from", r.RemoteAddr, "invalid overlay token")
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+// HandleControl monta uma rota que altera o estado do app, sempre exige o token de controle
+func (s *WebChatStreamServer) HandleControl(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.tokensMu.RLock()
+		allowed := tokenMatches(getRequestToken(r), s.controlToken)
+		s.tokensMu.RUnlock()
+		if !allowed {
+			log.Println("[WebChatStreamServer] Denying", r.URL.Path, "from", r.RemoteAddr, "invalid control token")
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+// getRequestToken aceita "Authorization: Bearer <token>" ou ?token= na URL, o WebSocket e o
+// EventSource do navegador não permitem cabeçalhos próprios
+func getRequestToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.URL.Query().Get("token")
+}
+
+func tokenMatches(token string, expected string) bool {
+	if token == "" || expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
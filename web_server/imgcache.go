@@ -0,0 +1,276 @@
+package web_server
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	IMAGE_CACHE_DIR        = "image_cache"
+	IMAGE_CACHE_MAX_BYTES  = 200 * 1024 * 1024
+	IMAGE_MAX_BYTES        = 5 * 1024 * 1024
+	IMAGE_PROXY_PATH       = "/img"
+	IMAGE_PROXY_MAX_AGE    = 7 * 24 * time.Hour
+	imageCacheTempFileMark = ".tmp"
+)
+
+// Apenas CDNs de emotes, badges e avatares passam pelo proxy, o resto é redirecionado para a URL original
+var imageProxyAllowedHosts = []string{
+	"static-cdn.jtvnw.net",
+	"d3aqoihi2n8ty8.cloudfront.net",
+	"cdn.betterttv.net",
+	"cdn.frankerfacez.com",
+	"cdn.7tv.app",
+	"yt3.ggpht.com",
+	"yt4.ggpht.com",
+	"yt3.googleusercontent.com",
+	"lh3.googleusercontent.com",
+	"files.kick.com",
+}
+
+// ProxyImageURL troca a URL de uma imagem externa pela rota local do proxy com cache
+func ProxyImageURL(imgUrl string) string {
+	if imgUrl == "" || strings.HasPrefix(imgUrl, IMAGE_PROXY_PATH+"?") || !isProxyAllowedURL(imgUrl) {
+		return imgUrl
+	}
+	return IMAGE_PROXY_PATH + "?url=" + url.QueryEscape(imgUrl)
+}
+
+func isProxyAllowedURL(imgUrl string) bool {
+	parsedUrl, err := url.Parse(imgUrl)
+	if err != nil || (parsedUrl.Scheme != "https" && parsedUrl.Scheme != "http") {
+		return false
+	}
+	host := parsedUrl.Hostname()
+	for _, allowed := range imageProxyAllowedHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+type imageCacheEntry struct {
+	key  string
+	size int64
+}
+
+// imageCache guarda as imagens em disco e descarta as usadas há mais tempo quando passa de maxBytes
+type imageCache struct {
+	dir       string
+	maxBytes  int64
+	client    http.Client
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	lru       *list.List // Mais recente na frente
+	totalSize int64
+}
+
+func newImageCache(dir string, maxBytes int64) *imageCache {
+	cache := &imageCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		client:   http.Client{Timeout: 10 * time.Second},
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		log.Println("[imageCache] Fail to create cache dir", err)
+		return cache
+	}
+	cache.loadFromDisk()
+	return cache
+}
+
+// loadFromDisk reconstrói a ordem de uso a partir da data de modificação dos arquivos
+func (c *imageCache) loadFromDisk() {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		log.Println("[imageCache] Fail to read cache dir", err)
+		return
+	}
+	type diskEntry struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	diskEntries := []diskEntry{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(file.Name(), imageCacheTempFileMark) {
+			os.Remove(filepath.Join(c.dir, file.Name()))
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		diskEntries = append(diskEntries, diskEntry{key: file.Name(), size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(diskEntries, func(i, j int) bool {
+		return diskEntries[i].modTime.Before(diskEntries[j].modTime)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range diskEntries {
+		c.entries[entry.key] = c.lru.PushFront(&imageCacheEntry{key: entry.key, size: entry.size})
+		c.totalSize += entry.size
+	}
+	c.evict()
+}
+
+func (c *imageCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	imgUrl := r.URL.Query().Get("url")
+	if !isProxyAllowedURL(imgUrl) {
+		http.Error(w, "invalid image url", http.StatusBadRequest)
+		return
+	}
+
+	key := imageCacheKey(imgUrl)
+	data, ok := c.get(key)
+	if !ok {
+		var err error
+		data, err = c.fetch(imgUrl, key)
+		if err != nil {
+			log.Println("[imageCache] Fail to fetch", imgUrl, err)
+			// Sem cópia local, deixa o navegador tentar direto na CDN
+			http.Redirect(w, r, imgUrl, http.StatusFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(IMAGE_PROXY_MAX_AGE.Seconds())))
+	w.Write(data)
+}
+
+func (c *imageCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	path := filepath.Join(c.dir, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.remove(key)
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now) // Mantém a ordem de uso entre execuções
+	return data, true
+}
+
+func (c *imageCache) fetch(imgUrl string, key string) ([]byte, error) {
+	resp, err := c.client.Get(imgUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &imageCacheError{message: "status code " + strconv.Itoa(resp.StatusCode)}
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, IMAGE_MAX_BYTES+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > IMAGE_MAX_BYTES {
+		return nil, &imageCacheError{message: "image is too large"}
+	}
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return nil, &imageCacheError{message: "response is not an image"}
+	}
+
+	c.put(key, data)
+	return data, nil
+}
+
+func (c *imageCache) put(key string, data []byte) {
+	// Temporário com nome único, duas requisições da mesma imagem podem baixá-la ao mesmo tempo
+	tempFile, err := os.CreateTemp(c.dir, key+"-*"+imageCacheTempFileMark)
+	if err != nil {
+		log.Println("[imageCache] Fail to create cache file", err)
+		return
+	}
+	_, err = tempFile.Write(data)
+	closeErr := tempFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), filepath.Join(c.dir, key))
+	}
+	if err != nil {
+		log.Println("[imageCache] Fail to write cache file", err)
+		os.Remove(tempFile.Name())
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.totalSize -= element.Value.(*imageCacheEntry).size
+		c.lru.Remove(element)
+	}
+	c.entries[key] = c.lru.PushFront(&imageCacheEntry{key: key, size: int64(len(data))})
+	c.totalSize += int64(len(data))
+	c.evict()
+}
+
+func (c *imageCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	c.totalSize -= element.Value.(*imageCacheEntry).size
+	c.lru.Remove(element)
+	delete(c.entries, key)
+}
+
+// evict deve ser chamado com mu travado
+func (c *imageCache) evict() {
+	for c.totalSize > c.maxBytes && c.lru.Len() > 0 {
+		element := c.lru.Back()
+		entry := element.Value.(*imageCacheEntry)
+		c.lru.Remove(element)
+		delete(c.entries, entry.key)
+		c.totalSize -= entry.size
+		os.Remove(filepath.Join(c.dir, entry.key))
+	}
+}
+
+func imageCacheKey(imgUrl string) string {
+	hash := sha256.Sum256([]byte(imgUrl))
+	return hex.EncodeToString(hash[:])
+}
+
+type imageCacheError struct {
+	message string
+}
+
+func (e *imageCacheError) Error() string {
+	return e.message
+}
@@ -2,16 +2,21 @@ package web_server
 
 import (
 	"log"
+	"net/http"
 	"overtube/save_state"
 )
 
+// CreateServer prepara o servidor com a porta do appState, quem chama deve chamar Start
 func CreateServer(appState *save_state.AppState) *WebChatStreamServer {
-	server := &WebChatStreamServer{Port: 1337, appState: appState}
-
-	log.Println("[CreateServer] Starting Web Server")
-	if !server.Start() {
-		return nil
+	server := &WebChatStreamServer{
+		Port:         appState.WebPort,
+		BindAddress:  appState.BindAddress,
+		mux:          http.NewServeMux(),
+		appState:     appState,
+		overlayToken: appState.OverlayToken,
+		controlToken: appState.ControlToken,
 	}
-	log.Println("[CreateServer] Web Server started")
+
+	log.Println("[CreateServer] Web Server created")
 	return server
 }
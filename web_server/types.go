@@ -3,11 +3,13 @@ package web_server
 import (
 	"context"
 	"embed"
-	"fmt"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"overtube/save_state"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -16,9 +18,15 @@ var content embed.FS
 
 type WebChatStreamServer struct {
 	Port              uint
+	BindAddress       string
+	mux               *http.ServeMux
 	srv               *http.Server
 	selectedChatStyle *ChatStyleOption
 	appState          *save_state.AppState
+	imgCache          *imageCache
+	overlayToken      string
+	controlToken      string
+	tokensMu          sync.RWMutex
 }
 
 func (s *WebChatStreamServer) SetSelectedChatStyle(style *ChatStyleOption) {
@@ -29,16 +37,29 @@ func (s *WebChatStreamServer) SetAppState(appState *save_state.AppState) {
 	s.appState = appState
 }
 
-func (s *WebChatStreamServer) Start() bool {
-	s.srv = &http.Server{Addr: "localhost:" + fmt.Sprintf("%d", s.Port)}
+// Handle monta outra rota pública no mesmo servidor da página, rotas com o chat usam HandleOverlay
+func (s *WebChatStreamServer) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start só retorna depois de reservar a porta, assim uma porta já em uso vira erro para a interface
+func (s *WebChatStreamServer) Start() error {
 	staticFiles, err := fs.Sub(content, "www")
 	if err != nil {
 		log.Println(err)
-		s.srv = nil
-		return false
+		return err
+	}
+	addr := net.JoinHostPort(s.BindAddress, strconv.Itoa(int(s.Port)))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Println("[WebChatStreamServer] Fail to listen on", addr, err)
+		return err
 	}
-	http.Handle("/", http.FileServer(http.FS(staticFiles)))
-	http.Handle("/styles.css", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	s.srv = &http.Server{Addr: addr, Handler: s.mux}
+	s.mux.Handle("/", http.FileServer(http.FS(staticFiles)))
+	s.imgCache = newImageCache(IMAGE_CACHE_DIR, IMAGE_CACHE_MAX_BYTES)
+	s.HandleOverlay(IMAGE_PROXY_PATH, s.imgCache)
+	s.mux.Handle("/styles.css", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/css")
 		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 		w.Header().Set("Pragma", "no-cache")
@@ -49,16 +70,29 @@ func (s *WebChatStreamServer) Start() bool {
 			w.Write([]byte(GetCurrentCSSForId(s.selectedChatStyle.Id, s.appState)))
 		}
 	}))
-	go s.srv.ListenAndServe()
-	return true
+	go func() {
+		err := s.srv.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Println("[WebChatStreamServer] Server stopped unexpectedly", err)
+		}
+	}()
+	log.Println("[WebChatStreamServer] Listening on", addr)
+	return nil
 }
 
-func (s *WebChatStreamServer) Stop() {
-	if s.srv != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		s.srv.Shutdown(ctx)
+// Stop espera as requisições em andamento, o ws_server deve parar antes para encerrar os streams abertos
+func (s *WebChatStreamServer) Stop() error {
+	if s.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.srv.Shutdown(ctx)
+	s.srv = nil
+	if err != nil {
+		log.Println("[WebChatStreamServer] Fail to stop server", err)
 	}
+	return err
 }
 
 type ChatStyleOption struct {
@@ -1,20 +1,53 @@
+// Versão do protocolo descrito em protocol.schema.json
+const PROTOCOL_VERSION = 1;
+
 var socket = null;
-var twEmoteMap = new Map();
-var ytEmoteMap = new Map();
-var platform = null;
+var subscription = {};
 
 window.addEventListener('load', () => {
     const queryParams = new URLSearchParams(window.location.search);
-    platform = queryParams.get('platform');
+    subscription = getSubscriptionFromQuery(queryParams);
 });
 
+// Os filtros do link do overlay são enviados ao servidor, que só repassa o que passar neles
+// ex: ?platform=twitch,kick&channel=canal&events=msg,raid&minRole=moderator&excludeBots=1
+function getSubscriptionFromQuery(queryParams) {
+    const list = (name) => (queryParams.get(name) || '').split(',').map(item => item.trim()).filter(item => item !== '');
+    return {
+        'type': 'subscribe',
+        'platforms': list('platform'),
+        'channels': list('channel'),
+        'eventTypes': list('events'),
+        'minRole': queryParams.get('minRole') || '',
+        'excludeBots': ['1', 'true'].includes(queryParams.get('excludeBots')),
+    };
+}
+
+// As imagens passam pelo proxy /img, que também pede o token do overlay quando o servidor está aberto para a rede
+function getImageSrc(src) {
+    const token = new URLSearchParams(window.location.search).get('token');
+    if(!token || !src.startsWith('/img?')) {
+        return src;
+    }
+    return src + '&token=' + encodeURIComponent(token);
+}
+
+// O WebSocket fica na mesma porta da página, assim funciona também no OBS de outro PC da rede
+function getWebSocketUrl() {
+    const protocol = window.location.protocol === 'https:' ? 'wss://' : 'ws://';
+    return protocol + window.location.host + '/ws';
+}
+
 function openWebSocket() {
     if(socket != null) return;
 
-    socket = new WebSocket("ws://localhost:1336/ws");
+    // Os filtros também vão na URL para que o histórico enviado ao conectar já chegue filtrado
+    socket = new WebSocket(getWebSocketUrl() + window.location.search);
     socket.onopen = (event) => {
         console.log("Websocket connected!");
         document.getElementById('alert-disconnected').style.display = 'none';
+        socket.send(JSON.stringify({'type': 'hello', 'protocolVersion': PROTOCOL_VERSION, 'client': 'overtube-overlay'}));
+        socket.send(JSON.stringify(subscription));
     }
     socket.onmessage = (event) => handleNewPayload(event.data);
 
@@ -37,48 +70,60 @@ function handleNewPayload(payload) {
     if(parsed.type === "msg") {
         handleNewMessage(parsed);
     }
+    if(parsed.type === "event") {
+        handleNewEvent(parsed);
+    }
     if(parsed.type === "cmd") {
         handleNewCommand(parsed);
     }
+    if(parsed.type === "welcome") {
+        console.log("Connected to OverTube protocol v" + parsed.protocolVersion, parsed.capabilities);
+    }
+    if(parsed.type === "error") {
+        console.error("OverTube server error:", parsed.message);
+    }
 }
 
 function handleNewCommand(command) {
-    if(command.command === 'ping') {
-        socket.send(JSON.stringify({'command': 'pong'}));
-    }
-    if(command.command === 'setNewUserId' && command.platform == 'twitch') {
-        twEmoteMap = new Map();
-        fillTwitchEmoteMap(twEmoteMap, command.id);
-    }
-    if(command.command === 'setNewUserId' && command.platform == 'youtube') {
-        ytEmoteMap = new Map();
-        fillYoutubeEmoteMap(ytEmoteMap, command.id)
+    if(command.command === 'clear') {
+        document.getElementById('messagesContainer').replaceChildren();
     }
     if(command.command === 'refresh') {
         window.location.reload();
     }
+    if(command.command === 'delete') {
+        deleteMessages(command);
+    }
+}
+
+function deleteMessages(command) {
+    const container = document.getElementById('messagesContainer');
+    Array.from(container.children).forEach(node => {
+        if(node.dataset.platform !== command.platform) return;
+        if(command.channel && node.dataset.channel !== command.channel) return;
+        if(command.deletionType === 'all' ||
+            (command.deletionType === 'message' && command.messageId && node.dataset.messageId === command.messageId) ||
+            (command.deletionType === 'user' && command.userId && node.dataset.userId === command.userId)) {
+            container.removeChild(node);
+        }
+    });
 }
 
 
 function handleNewMessage(message) {
-    switch (message.platform) {
-        case 'youtube':
-            if(platform !== null && platform !== 'youtube') return;
-            breakMessage(message, ytEmoteMap);
-            break;
-        case 'twitch':
-            if(platform !== null && platform !== 'twitch') return;
-            breakMessage(message, twEmoteMap);
-            break;
-        default:
-            break;
-    }
     const node = createMessageNode(message);
     document.getElementById('messagesContainer').appendChild(node);
     deleteOldMessages();
     window.scrollTo(0, document.body.scrollHeight);
 }
 
+function handleNewEvent(event) {
+    const node = createEventNode(event);
+    document.getElementById('messagesContainer').appendChild(node);
+    deleteOldMessages();
+    window.scrollTo(0, document.body.scrollHeight);
+}
+
 function deleteOldMessages() {
     const container = document.getElementById('messagesContainer');
     const nToRemove = container.children.length - 100;
@@ -90,6 +135,14 @@ function deleteOldMessages() {
 function createMessageNode(message) {
     const container = document.createElement('div');
     container.classList.add('message-container');
+    container.dataset.platform = message.platform;
+    container.dataset.channel = message.channel || '';
+    container.dataset.messageId = message.id || '';
+    container.dataset.userId = message.userId || '';
+    if(message.bits) {
+        container.classList.add('message-cheer');
+        container.dataset.bits = message.bits;
+    }
     container.appendChild(createOuterPlatformMessageNode(message));
     container.appendChild(createHeaderMessageNode(message));
     container.appendChild(createBodyMessageNode(message));
@@ -97,6 +150,36 @@ function createMessageNode(message) {
     return container
 }
 
+function createEventNode(event) {
+    const container = createMessageNode(event);
+    container.classList.add('event-container');
+    container.classList.add('event-' + event.eventType);
+    if(event.color) {
+        container.classList.add('event-color-' + event.color.toLowerCase());
+    }
+
+    const systemText = document.createElement('div');
+    systemText.classList.add('event-system-text');
+    systemText.innerText = event.systemText;
+
+    const body = container.querySelector('.message-body-container');
+    body.insertBefore(systemText, body.firstChild);
+
+    if(event.stickerImgUrl) {
+        const sticker = document.createElement('img');
+        sticker.src = getImageSrc(event.stickerImgUrl);
+        sticker.classList.add('event-sticker-img');
+        body.appendChild(sticker);
+    }
+    if(event.headerColor) {
+        container.style.setProperty('--event-header-color', event.headerColor);
+    }
+    if(event.bodyColor) {
+        container.style.setProperty('--event-body-color', event.bodyColor);
+    }
+    return container
+}
+
 function createHeaderMessageNode(message) {
     const container = document.createElement('div');
     container.classList.add('message-head-container');
@@ -109,6 +192,13 @@ function createHeaderMessageNode(message) {
     name.innerText = message.userName;
     container.appendChild(name);
 
+    if(message.channelLabel) {
+        const label = document.createElement('div');
+        label.classList.add('message-head-channel-label');
+        label.innerText = message.channelLabel;
+        container.appendChild(label);
+    }
+
     return container
 }
 
@@ -116,10 +206,7 @@ function createOuterPlatformMessageNode(message) {
     const container = document.createElement('div');
     container.classList.add('message-outer-platform-icon-container');
     const img = document.createElement('img');
-    img.src = '/platform_icons/yt.png';
-    if(message.platform === 'twitch') {
-        img.src = '/platform_icons/tw.png';
-    }
+    img.src = getPlatformIconSrc(message.platform);
     img.classList.add('outer-platform-icon-img');
     container.appendChild(img);
 
@@ -130,23 +217,39 @@ function createHeaderPlatformMessageNode(message) {
     const container = document.createElement('div');
     container.classList.add('message-header-platform-icon-container');
     const img = document.createElement('img');
-    img.src = '/platform_icons/yt.png';
-    if(message.platform === 'twitch') {
-        img.src = '/platform_icons/tw.png';
-    }
+    img.src = getPlatformIconSrc(message.platform);
     img.classList.add('badge-platform-icon-img');
     container.appendChild(img);
 
     return container
 }
 
+function getPlatformIconSrc(messagePlatform) {
+    if(messagePlatform === 'twitch') {
+        return '/platform_icons/tw.png';
+    }
+    if(messagePlatform === 'kick') {
+        return '/platform_icons/kick.png';
+    }
+    return '/platform_icons/yt.png';
+}
+
 function createHeadeBadgesMessageNode(message) {
     const container = document.createElement('div');
     container.classList.add('message-head-badges-container');
 
     message.badges.forEach(badge => {
+        // Selos sem imagem viram texto com a classe do tipo, para o CSS do chat estilizar
+        if(!badge.ImgSrc) {
+            const span = document.createElement('span');
+            span.innerText = badge.Name;
+            span.classList.add('message-badge-text', 'message-badge-' + badge.Type);
+            span.setAttribute('data-tooltip', badge.Name);
+            container.appendChild(span);
+            return;
+        }
         const img = document.createElement('img');
-        img.src = badge.ImgSrc;
+        img.src = getImageSrc(badge.ImgSrc);
         img.classList.add('message-badge-img');
         img.setAttribute('data-tooltip', badge.Name);
         container.appendChild(img);
@@ -165,7 +268,7 @@ function createBodyMessageNode(message) {
             container.appendChild(span);
         } else {
             const img = document.createElement('img');
-            img.src = part.EmoteImgUrl;
+            img.src = getImageSrc(part.EmoteImgUrl);
             img.classList.add('message-emote-img');
             container.appendChild(img);
         }
@@ -177,10 +280,7 @@ function createFooterMessageNode(message) {
     const container = document.createElement('div');
     container.classList.add('message-footer-container');
     const img = document.createElement('img');
-    img.src = '/platform_icons/yt.png';
-    if(message.platform === 'twitch') {
-        img.src = '/platform_icons/tw.png';
-    }
+    img.src = getPlatformIconSrc(message.platform);
     img.classList.add('platform-icon-img');
     container.appendChild(img);
     return container
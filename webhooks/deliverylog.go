@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+)
+
+// deliveryLog grava cada tentativa de entrega em JSON Lines
+type deliveryLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newDeliveryLog(filePath string) *deliveryLog {
+	deliveryLog := &deliveryLog{}
+	if filePath == "" {
+		return deliveryLog
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Println("[webhooks] Fail to open delivery log", err)
+		return deliveryLog
+	}
+	deliveryLog.file = file
+	return deliveryLog
+}
+
+func (l *deliveryLog) Write(record DeliveryRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Println("[webhooks] Fail to encode delivery record", err)
+		return
+	}
+	_, err = l.file.Write(append(line, '\n'))
+	if err != nil {
+		log.Println("[webhooks] Fail to write delivery record", err)
+	}
+}
+
+func (l *deliveryLog) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"overtube/save_state"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// SetTargets troca os destinos configurados, entregas ainda na fila dos destinos antigos são descartadas
+func (d *Dispatcher) SetTargets(configs []save_state.WebhookConfig) {
+	targets := []*target{}
+	for _, config := range configs {
+		if !config.Enabled || config.URL == "" {
+			continue
+		}
+		t := &target{
+			config: config,
+			queue:  make(chan queuedDelivery, TARGET_QUEUE_SIZE),
+			stop:   make(chan struct{}),
+		}
+		if config.MessagePattern != "" {
+			pattern, err := regexp.Compile("(?i)" + config.MessagePattern)
+			if err != nil {
+				log.Println("[webhooks] Invalid message pattern for", config.Id, err)
+				// Sem o padrão válido nenhuma mensagem é enviada, só os eventos
+				t.config.Kinds = slices.DeleteFunc(slices.Clone(config.Kinds), func(kind string) bool {
+					return kind == KIND_MESSAGE
+				})
+			}
+			t.pattern = pattern
+		}
+		targets = append(targets, t)
+	}
+
+	d.mu.Lock()
+	oldTargets := d.targets
+	d.targets = targets
+	for _, t := range targets {
+		d.workers.Add(1)
+		go d.runTarget(t)
+	}
+	d.mu.Unlock()
+
+	for _, t := range oldTargets {
+		close(t.stop)
+	}
+}
+
+// Dispatch coloca o evento na fila de cada destino interessado sem bloquear quem chamou
+func (d *Dispatcher) Dispatch(event Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.targets) == 0 {
+		return
+	}
+	body := DeliveryBody{
+		DeliveryId: newDeliveryId(),
+		Kind:       event.Kind,
+		Timestamp:  time.Now().Unix(),
+		Payload:    event.Payload,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Println("[webhooks] Fail to encode delivery", err)
+		return
+	}
+	for _, t := range d.targets {
+		if !t.accepts(event) {
+			continue
+		}
+		select {
+		case t.queue <- queuedDelivery{body: body, data: data}:
+		default:
+			log.Println("[webhooks] Queue full, dropping delivery for", t.config.Id)
+			d.log.Write(DeliveryRecord{
+				At:         time.Now().UnixMilli(),
+				DeliveryId: body.DeliveryId,
+				WebhookId:  t.config.Id,
+				URL:        t.config.URL,
+				Kind:       body.Kind,
+				Error:      "queue full",
+			})
+		}
+	}
+}
+
+func (d *Dispatcher) Stop() {
+	d.SetTargets(nil)
+	d.workers.Wait()
+	d.log.Close()
+}
+
+func (t *target) accepts(event Event) bool {
+	if !slices.Contains(t.config.Kinds, event.Kind) {
+		return false
+	}
+	if event.Kind == KIND_MESSAGE && t.pattern != nil {
+		return t.pattern.MatchString(event.Text)
+	}
+	return true
+}
+
+// runTarget entrega na ordem em que os eventos chegaram, um evento só sai depois das retentativas do anterior
+func (d *Dispatcher) runTarget(t *target) {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case delivery := <-t.queue:
+			d.deliver(t, delivery)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(t *target, delivery queuedDelivery) {
+	delay := DELIVERY_RETRY_DELAY
+	for attempt := 1; attempt <= DELIVERY_MAX_ATTEMPTS; attempt++ {
+		startedAt := time.Now()
+		statusCode, err := d.post(t, delivery)
+		record := DeliveryRecord{
+			At:         startedAt.UnixMilli(),
+			DeliveryId: delivery.body.DeliveryId,
+			WebhookId:  t.config.Id,
+			URL:        t.config.URL,
+			Kind:       delivery.body.Kind,
+			Attempt:    attempt,
+			StatusCode: statusCode,
+			DurationMs: time.Since(startedAt).Milliseconds(),
+			Delivered:  err == nil,
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		d.log.Write(record)
+		if err == nil || !shouldRetry(statusCode) {
+			return
+		}
+
+		select {
+		case <-t.stop:
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, DELIVERY_MAX_DELAY)
+	}
+	log.Println("[webhooks] Giving up delivery", delivery.body.DeliveryId, "to", t.config.Id)
+}
+
+func (d *Dispatcher) post(t *target, delivery queuedDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, t.config.URL, bytes.NewReader(delivery.data))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(delivery.body.Timestamp, 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OverTube-Webhooks")
+	req.Header.Set(KIND_HEADER, delivery.body.Kind)
+	req.Header.Set(DELIVERY_HEADER, delivery.body.DeliveryId)
+	req.Header.Set(TIMESTAMP_HEADER, timestamp)
+	if t.config.Secret != "" {
+		req.Header.Set(SIGNATURE_HEADER, "sha256="+Sign(t.config.Secret, timestamp, delivery.data))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, &webhookError{message: "unexpected status code " + strconv.Itoa(resp.StatusCode)}
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign calcula o HMAC-SHA256 de "timestamp.corpo", quem recebe deve refazer a conta com o mesmo segredo
+// e rejeitar timestamps antigos para evitar reenvios
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// shouldRetry não repete erros do cliente, exceto 408 e 429, pois a resposta não vai mudar
+func shouldRetry(statusCode int) bool {
+	if statusCode == 0 || statusCode >= 500 {
+		return true
+	}
+	return statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests
+}
+
+func newDeliveryId() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
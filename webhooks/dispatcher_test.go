@@ -0,0 +1,146 @@
+package webhooks
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"overtube/save_state"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type receivedDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+// fakeReceiver responde com os status em ordem, repetindo o último, e repassa cada POST recebido
+func fakeReceiver(statuses ...int) (*httptest.Server, <-chan receivedDelivery) {
+	received := make(chan receivedDelivery, 10)
+	attempt := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		status := statuses[min(attempt, len(statuses)-1)]
+		attempt++
+		w.WriteHeader(status)
+		received <- receivedDelivery{header: r.Header.Clone(), body: body}
+	}))
+	return server, received
+}
+
+func waitDelivery(t *testing.T, received <-chan receivedDelivery, timeout time.Duration) receivedDelivery {
+	select {
+	case delivery := <-received:
+		return delivery
+	case <-time.After(timeout):
+		t.Fatal("timeout waiting for webhook delivery")
+		return receivedDelivery{}
+	}
+}
+
+func newTestDispatcher(logFilePath string, config save_state.WebhookConfig) *Dispatcher {
+	dispatcher := NewDispatcher(logFilePath)
+	config.Enabled = true
+	config.Kinds = []string{KIND_MESSAGE}
+	dispatcher.SetTargets([]save_state.WebhookConfig{config})
+	return dispatcher
+}
+
+func TestDeliverySignature(t *testing.T) {
+	server, received := fakeReceiver(http.StatusOK)
+	defer server.Close()
+	dispatcher := newTestDispatcher("", save_state.WebhookConfig{Id: "a", URL: server.URL, Secret: "segredo"})
+	defer dispatcher.Stop()
+
+	dispatcher.Dispatch(Event{Kind: KIND_MESSAGE, Text: "oi chat", Payload: json.RawMessage(`{"text":"oi chat"}`)})
+	delivery := waitDelivery(t, received, 5*time.Second)
+
+	timestamp := delivery.header.Get(TIMESTAMP_HEADER)
+	if timestamp == "" {
+		t.Fatal("missing timestamp header")
+	}
+	expected := "sha256=" + Sign("segredo", timestamp, delivery.body)
+	if got := delivery.header.Get(SIGNATURE_HEADER); got != expected {
+		t.Errorf("signature = %q, want %q", got, expected)
+	}
+	if got := delivery.header.Get(KIND_HEADER); got != KIND_MESSAGE {
+		t.Errorf("kind header = %q", got)
+	}
+	var body DeliveryBody
+	if err := json.Unmarshal(delivery.body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.DeliveryId != delivery.header.Get(DELIVERY_HEADER) || string(body.Payload) != `{"text":"oi chat"}` {
+		t.Errorf("unexpected body %s", delivery.body)
+	}
+}
+
+func TestDeliveryWithoutSecretIsNotSigned(t *testing.T) {
+	server, received := fakeReceiver(http.StatusOK)
+	defer server.Close()
+	dispatcher := newTestDispatcher("", save_state.WebhookConfig{Id: "a", URL: server.URL})
+	defer dispatcher.Stop()
+
+	dispatcher.Dispatch(Event{Kind: KIND_MESSAGE, Payload: json.RawMessage(`{}`)})
+	if got := waitDelivery(t, received, 5*time.Second).header.Get(SIGNATURE_HEADER); got != "" {
+		t.Errorf("unexpected signature %q", got)
+	}
+}
+
+func TestDeliveryRetriesOnServerError(t *testing.T) {
+	server, received := fakeReceiver(http.StatusInternalServerError, http.StatusOK)
+	defer server.Close()
+	logFilePath := filepath.Join(t.TempDir(), DELIVERY_LOG_FILE)
+	dispatcher := newTestDispatcher(logFilePath, save_state.WebhookConfig{Id: "a", URL: server.URL})
+
+	dispatcher.Dispatch(Event{Kind: KIND_MESSAGE, Payload: json.RawMessage(`{}`)})
+	first := waitDelivery(t, received, 5*time.Second)
+	second := waitDelivery(t, received, DELIVERY_RETRY_DELAY+5*time.Second)
+	if first.header.Get(DELIVERY_HEADER) != second.header.Get(DELIVERY_HEADER) {
+		t.Error("retry used a different delivery id")
+	}
+	dispatcher.Stop()
+
+	file, err := os.Open(logFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records := []DeliveryRecord{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record DeliveryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("logged %d attempts, want 2: %+v", len(records), records)
+	}
+	if records[0].Delivered || records[0].StatusCode != http.StatusInternalServerError || records[0].Attempt != 1 {
+		t.Errorf("unexpected first attempt %+v", records[0])
+	}
+	if !records[1].Delivered || records[1].StatusCode != http.StatusOK || records[1].Attempt != 2 {
+		t.Errorf("unexpected second attempt %+v", records[1])
+	}
+}
+
+func TestDeliveryDoesNotRetryClientError(t *testing.T) {
+	server, received := fakeReceiver(http.StatusBadRequest)
+	defer server.Close()
+	dispatcher := newTestDispatcher("", save_state.WebhookConfig{Id: "a", URL: server.URL})
+	defer dispatcher.Stop()
+
+	dispatcher.Dispatch(Event{Kind: KIND_MESSAGE, Payload: json.RawMessage(`{}`)})
+	waitDelivery(t, received, 5*time.Second)
+	select {
+	case <-received:
+		t.Error("a 400 response was retried")
+	case <-time.After(DELIVERY_RETRY_DELAY + time.Second):
+	}
+}
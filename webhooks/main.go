@@ -0,0 +1,14 @@
+package webhooks
+
+import (
+	"log"
+	"net/http"
+)
+
+func NewDispatcher(logFilePath string) *Dispatcher {
+	log.Println("[webhooks] Starting webhook dispatcher")
+	return &Dispatcher{
+		client: http.Client{Timeout: DELIVERY_TIMEOUT},
+		log:    newDeliveryLog(logFilePath),
+	}
+}
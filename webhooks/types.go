@@ -0,0 +1,87 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"overtube/save_state"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	DELIVERY_LOG_FILE     = "webhook_deliveries.jsonl"
+	DELIVERY_TIMEOUT      = 10 * time.Second
+	DELIVERY_MAX_ATTEMPTS = 5
+	DELIVERY_RETRY_DELAY  = 2 * time.Second
+	DELIVERY_MAX_DELAY    = time.Minute
+	TARGET_QUEUE_SIZE     = 100
+
+	SIGNATURE_HEADER = "X-OverTube-Signature"
+	TIMESTAMP_HEADER = "X-OverTube-Timestamp"
+	KIND_HEADER      = "X-OverTube-Kind"
+	DELIVERY_HEADER  = "X-OverTube-Delivery"
+
+	// Tipo usado nos Kinds dos webhooks para as mensagens normais do chat, o resto são tipos de evento
+	KIND_MESSAGE = "msg"
+)
+
+// Event é o que o servidor do overlay repassa para os webhooks, Payload é o mesmo JSON enviado no /ws
+type Event struct {
+	Kind     string
+	Platform string
+	Channel  string
+	Text     string
+	Payload  json.RawMessage
+}
+
+// DeliveryBody é o corpo do POST enviado para cada destino
+type DeliveryBody struct {
+	DeliveryId string          `json:"deliveryId"`
+	Kind       string          `json:"kind"`
+	Timestamp  int64           `json:"timestamp"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// DeliveryRecord é uma linha do log de entregas, uma por tentativa
+type DeliveryRecord struct {
+	At         int64  `json:"at"`
+	DeliveryId string `json:"deliveryId"`
+	WebhookId  string `json:"webhookId"`
+	URL        string `json:"url"`
+	Kind       string `json:"kind"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Delivered  bool   `json:"delivered"`
+}
+
+type queuedDelivery struct {
+	body DeliveryBody
+	data []byte
+}
+
+// target é um webhook configurado com sua própria fila, um destino lento não atrasa os outros
+type target struct {
+	config  save_state.WebhookConfig
+	pattern *regexp.Regexp
+	queue   chan queuedDelivery
+	stop    chan struct{}
+}
+
+type Dispatcher struct {
+	mu      sync.RWMutex
+	targets []*target
+	client  http.Client
+	log     *deliveryLog
+	workers sync.WaitGroup
+}
+
+type webhookError struct {
+	message string
+}
+
+func (e *webhookError) Error() string {
+	return e.message
+}
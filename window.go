@@ -0,0 +1,11 @@
+//go:build !headless
+
+package main
+
+import "overtube/ui"
+
+// startWindow abre a janela do Gio, compilar com -tags headless remove a janela e as suas dependências
+func startWindow(uiEventChan chan ui.UIEvent) bool {
+	go ui.CreateHomeWindow(uiEventChan, uiCommandsChan, appState)
+	return true
+}
@@ -0,0 +1,13 @@
+//go:build headless
+
+package main
+
+import (
+	"log"
+	"overtube/ui"
+)
+
+func startWindow(uiEventChan chan ui.UIEvent) bool {
+	log.Println("Built without the window, running headless")
+	return false
+}
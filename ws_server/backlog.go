@@ -0,0 +1,118 @@
+package ws_server
+
+import (
+	"overtube/chat_stream"
+	"sync"
+)
+
+const (
+	DEFAULT_BACKLOG_SIZE = 50
+	// Precisa caber na fila do cliente junto com as mensagens novas, senão o histórico é descartado
+	MAX_BACKLOG_SIZE = CLIENT_SEND_QUEUE_SIZE / 2
+)
+
+type backlogEntry struct {
+	id        uint64
+	meta      payloadMeta
+	messageId string
+	userId    string
+	payload   []byte
+}
+
+func (e backlogEntry) queued() queuedPayload {
+	return queuedPayload{id: e.id, data: e.payload}
+}
+
+// chatBacklog é um buffer circular com os últimos payloads enviados, reenviados para cada overlay
+// que conecta para que o chat não fique vazio depois de recarregar a fonte no OBS
+type chatBacklog struct {
+	mu      sync.Mutex
+	entries []backlogEntry
+	start   int
+	length  int
+	lastId  uint64
+}
+
+func newChatBacklog(size int) *chatBacklog {
+	return &chatBacklog{entries: make([]backlogEntry, clampBacklogSize(size))}
+}
+
+func clampBacklogSize(size int) int {
+	return max(0, min(size, MAX_BACKLOG_SIZE))
+}
+
+// resize deve ser chamado com mu travado, mantém as entradas mais recentes que couberem
+func (b *chatBacklog) resize(size int) {
+	entries := b.snapshot(nil)
+	size = clampBacklogSize(size)
+	if len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+	b.entries = make([]backlogEntry, size)
+	copy(b.entries, entries)
+	b.start = 0
+	b.length = len(entries)
+}
+
+// nextId numera os payloads em ordem, usado como id dos Server-Sent Events, deve ser chamado com mu travado
+func (b *chatBacklog) nextId() uint64 {
+	b.lastId++
+	return b.lastId
+}
+
+// push deve ser chamado com mu travado
+func (b *chatBacklog) push(entry backlogEntry) {
+	capacity := len(b.entries)
+	if capacity == 0 {
+		return
+	}
+	if b.length < capacity {
+		b.entries[(b.start+b.length)%capacity] = entry
+		b.length++
+		return
+	}
+	b.entries[b.start] = entry
+	b.start = (b.start + 1) % capacity
+}
+
+// snapshot devolve as entradas da mais antiga para a mais nova, filtro nil não filtra,
+// deve ser chamado com mu travado
+func (b *chatBacklog) snapshot(filter *clientFilter) []backlogEntry {
+	entries := make([]backlogEntry, 0, b.length)
+	for i := 0; i < b.length; i++ {
+		entry := b.entries[(b.start+i)%len(b.entries)]
+		if filter == nil || filter.accepts(entry.meta) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// delete aplica uma remoção de mensagens no histórico com as mesmas regras do overlay,
+// deve ser chamado com mu travado
+func (b *chatBacklog) delete(deletion chat_stream.ChatStreamDeletion, channel string) {
+	kept := []backlogEntry{}
+	for _, entry := range b.snapshot(nil) {
+		if entry.meta.platform != deletion.Platform || (channel != "" && entry.meta.channel != channel) {
+			kept = append(kept, entry)
+			continue
+		}
+		switch deletion.DeletionType {
+		case chat_stream.ChatStreamDeletionTypeAll:
+			continue
+		case chat_stream.ChatStreamDeletionTypeMessage:
+			if deletion.MessageId != "" && entry.messageId == deletion.MessageId {
+				continue
+			}
+		case chat_stream.ChatStreamDeletionTypeUser:
+			if deletion.UserId != "" && entry.userId == deletion.UserId {
+				continue
+			}
+		}
+		kept = append(kept, entry)
+	}
+	clear(b.entries)
+	copy(b.entries, kept)
+	b.start = 0
+	b.length = len(kept)
+}
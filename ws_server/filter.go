@@ -0,0 +1,95 @@
+package ws_server
+
+import (
+	"net/url"
+	"overtube/chat_stream"
+	"slices"
+	"strings"
+)
+
+// Tipo usado em clientFilter.EventTypes para as mensagens normais do chat
+const FILTER_TYPE_MESSAGE = "msg"
+
+// clientFilter é enviado pelo overlay na mensagem "subscribe", campos vazios não filtram nada
+type clientFilter struct {
+	Platforms   []chat_stream.PlatformType `json:"platforms"`
+	Channels    []string                   `json:"channels"`
+	EventTypes  []string                   `json:"eventTypes"`
+	MinRole     chat_stream.ChatUserRole   `json:"minRole"`
+	ExcludeBots bool                       `json:"excludeBots"`
+}
+
+// payloadMeta resume um payload para os filtros, kind vazio indica um comando que vai para todos
+type payloadMeta struct {
+	platform chat_stream.PlatformType
+	channel  string
+	kind     string
+	role     chat_stream.ChatUserRole
+	isBot    bool
+}
+
+// parseFilterQuery lê os mesmos parâmetros do link do overlay: platform, channel, events, minRole e excludeBots
+func parseFilterQuery(query url.Values) clientFilter {
+	filter := clientFilter{
+		Channels:    splitFilterQueryList(query.Get("channel")),
+		EventTypes:  splitFilterQueryList(query.Get("events")),
+		MinRole:     chat_stream.ChatUserRole(query.Get("minRole")),
+		ExcludeBots: query.Get("excludeBots") == "1" || query.Get("excludeBots") == "true",
+	}
+	for _, platform := range splitFilterQueryList(query.Get("platform")) {
+		filter.Platforms = append(filter.Platforms, chat_stream.PlatformType(platform))
+	}
+	filter.normalize()
+	return filter
+}
+
+func splitFilterQueryList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func (f *clientFilter) normalize() {
+	if !chat_stream.IsValidChatUserRole(string(f.MinRole)) {
+		f.MinRole = ""
+	}
+	for i, channel := range f.Channels {
+		f.Channels[i] = strings.ToLower(channel)
+	}
+}
+
+func (f *clientFilter) equals(other clientFilter) bool {
+	return slices.Equal(f.Platforms, other.Platforms) &&
+		slices.Equal(f.Channels, other.Channels) &&
+		slices.Equal(f.EventTypes, other.EventTypes) &&
+		f.MinRole == other.MinRole &&
+		f.ExcludeBots == other.ExcludeBots
+}
+
+func (f *clientFilter) accepts(meta payloadMeta) bool {
+	if meta.kind == "" {
+		return true
+	}
+	if len(f.Platforms) > 0 && !slices.Contains(f.Platforms, meta.platform) {
+		return false
+	}
+	if len(f.Channels) > 0 && !slices.Contains(f.Channels, strings.ToLower(meta.channel)) {
+		return false
+	}
+	if len(f.EventTypes) > 0 && !slices.Contains(f.EventTypes, meta.kind) {
+		return false
+	}
+	// Cargo e bots só se aplicam às mensagens, eventos como raids e subs não têm autor no chat
+	if meta.kind != FILTER_TYPE_MESSAGE {
+		return true
+	}
+	if f.MinRole != "" && meta.role.Rank() < f.MinRole.Rank() {
+		return false
+	}
+	return !f.ExcludeBots || !meta.isBot
+}
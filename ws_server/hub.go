@@ -0,0 +1,239 @@
+package ws_server
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	CLIENT_SEND_QUEUE_SIZE = 256
+	// Depois de tantas mensagens descartadas seguidas o cliente é considerado travado e desconectado
+	CLIENT_MAX_DROPPED = 64
+	CLIENT_WRITE_WAIT  = 10 * time.Second
+	CLIENT_PONG_WAIT   = 30 * time.Second
+	CLIENT_PING_PERIOD = CLIENT_PONG_WAIT * 9 / 10
+	CLIENT_MAX_READ    = 4096
+)
+
+// wsHub distribui os payloads para todos os overlays conectados, cada cliente tem sua própria
+// fila e goroutines de leitura e escrita, então um cliente lento não atrasa os outros
+type wsHub struct {
+	mu        sync.RWMutex
+	clients   map[*wsClient]struct{}
+	onMessage func(client *wsClient, message []byte)
+}
+
+func newWSHub(onMessage func(client *wsClient, message []byte)) *wsHub {
+	return &wsHub{clients: map[*wsClient]struct{}{}, onMessage: onMessage}
+}
+
+// queuedPayload é um item da fila de um cliente, id é a posição no backlog e fica 0 para os
+// payloads que não entram nele (comandos, welcome...)
+type queuedPayload struct {
+	id   uint64
+	data []byte
+}
+
+func (h *wsHub) register(conn *websocket.Conn, filter clientFilter) *wsClient {
+	client := h.add(conn, filter)
+	if client != nil {
+		go client.writePump()
+		go client.readPump()
+	}
+	return client
+}
+
+// registerQueue adiciona um cliente sem WebSocket, quem consome a fila send é o próprio chamador
+func (h *wsHub) registerQueue(filter clientFilter) *wsClient {
+	return h.add(nil, filter)
+}
+
+func (h *wsHub) add(conn *websocket.Conn, filter clientFilter) *wsClient {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.countLocked(conn == nil) >= maxConns(conn == nil) {
+		return nil
+	}
+	client := &wsClient{
+		hub:  h,
+		conn: conn,
+		send: make(chan queuedPayload, CLIENT_SEND_QUEUE_SIZE),
+		done: make(chan struct{}),
+	}
+	client.filter.Store(&filter)
+	h.clients[client] = struct{}{}
+	return client
+}
+
+func (h *wsHub) unregister(client *wsClient) {
+	h.mu.Lock()
+	_, ok := h.clients[client]
+	delete(h.clients, client)
+	h.mu.Unlock()
+	if ok {
+		client.close()
+	}
+}
+
+// full diz se não cabe mais um cliente do tipo, os clientes SSE são os que não têm WebSocket
+func (h *wsHub) full(sse bool) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.countLocked(sse) >= maxConns(sse)
+}
+
+func (h *wsHub) countLocked(sse bool) int {
+	count := 0
+	for client := range h.clients {
+		if (client.conn == nil) == sse {
+			count++
+		}
+	}
+	return count
+}
+
+func maxConns(sse bool) int {
+	if sse {
+		return MAX_SSE_CONNS
+	}
+	return MAX_WS_CONNS
+}
+
+// broadcast serializa o payload uma única vez e o coloca na fila de cada cliente sem bloquear
+func (h *wsHub) broadcast(data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Println("[wsHub] Fail to encode payload", err)
+		return
+	}
+	h.broadcastPayload(queuedPayload{data: payload}, payloadMeta{})
+}
+
+// broadcastPayload envia o payload já serializado apenas para os clientes cujo filtro o aceita
+func (h *wsHub) broadcastPayload(payload queuedPayload, meta payloadMeta) {
+	stuckClients := []*wsClient{}
+	h.mu.RLock()
+	for client := range h.clients {
+		if !client.getFilter().accepts(meta) {
+			continue
+		}
+		if !client.enqueue(payload) {
+			stuckClients = append(stuckClients, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range stuckClients {
+		log.Println("[wsHub] Client is not consuming messages, disconnecting")
+		h.unregister(client)
+	}
+}
+
+func (h *wsHub) closeAll() {
+	h.mu.Lock()
+	clients := h.clients
+	h.clients = map[*wsClient]struct{}{}
+	h.mu.Unlock()
+	for client := range clients {
+		client.close()
+	}
+}
+
+type wsClient struct {
+	hub       *wsHub
+	conn      *websocket.Conn
+	send      chan queuedPayload
+	done      chan struct{}
+	dropped   int
+	dropMu    sync.Mutex
+	closeOnce sync.Once
+	filter    atomic.Pointer[clientFilter]
+}
+
+func (c *wsClient) getFilter() *clientFilter {
+	return c.filter.Load()
+}
+
+func (c *wsClient) setFilter(filter clientFilter) {
+	c.filter.Store(&filter)
+}
+
+// enqueue descarta a mensagem se a fila do cliente estiver cheia, retorna false se o cliente
+// já descartou mensagens demais em sequência
+func (c *wsClient) enqueue(payload queuedPayload) bool {
+	c.dropMu.Lock()
+	defer c.dropMu.Unlock()
+	select {
+	case <-c.done:
+		return true
+	case c.send <- payload:
+		c.dropped = 0
+		return true
+	default:
+		c.dropped++
+		return c.dropped < CLIENT_MAX_DROPPED
+	}
+}
+
+// close sinaliza o writePump, que envia o frame de fechamento e encerra a conexão
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// readPump processa os pongs, detecta a desconexão e repassa as mensagens do overlay para o servidor
+func (c *wsClient) readPump() {
+	defer c.hub.unregister(c)
+	c.conn.SetReadLimit(CLIENT_MAX_READ)
+	c.conn.SetReadDeadline(time.Now().Add(CLIENT_PONG_WAIT))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(CLIENT_PONG_WAIT))
+	})
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Println("[wsClient] Client disconnected", err)
+			}
+			return
+		}
+		if c.hub.onMessage != nil {
+			c.hub.onMessage(c, message)
+		}
+	}
+}
+
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(CLIENT_PING_PERIOD)
+	defer func() {
+		ticker.Stop()
+		c.hub.unregister(c)
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(CLIENT_WRITE_WAIT))
+			return
+		case payload := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(CLIENT_WRITE_WAIT))
+			err := c.conn.WriteMessage(websocket.TextMessage, payload.data)
+			if err != nil {
+				log.Println("[wsClient] Fail to write to client", err)
+				return
+			}
+		case <-ticker.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(CLIENT_WRITE_WAIT))
+			if err != nil {
+				log.Println("[wsClient] No response from client, disconnected", err)
+				return
+			}
+		}
+	}
+}
@@ -3,18 +3,29 @@ package ws_server
 import (
 	"log"
 	"overtube/chat_stream"
+	"overtube/save_state"
+
+	"github.com/gorilla/websocket"
 )
 
-func CreateServer() *WSChatStreamServer {
+// CreateServer prepara o servidor com as origens do appState, o /ws é atendido por ServeWS
+func CreateServer(appState *save_state.AppState) *WSChatStreamServer {
 	server := &WSChatStreamServer{
-		Port:       1336,
-		srcStreams: make([]chat_stream.ChatStreamCon, 0),
+		AllowedOrigins:  appState.AllowedOrigins,
+		srcStreams:      make([]*sourceStream, 0),
+		backlog:         newChatBacklog(DEFAULT_BACKLOG_SIZE),
+		emotes:          chat_stream.NewEmoteProvider(),
+		stopped:         make(chan struct{}),
+		StatusEventChan: make(chan ChannelConnectionStatusEvent),
 	}
-
-	log.Println("[CreateServer] Starting WS Server")
-	if !server.Start() {
-		return nil
+	server.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 2048,
+		CheckOrigin:     server.checkOrigin,
 	}
-	log.Println("[CreateServer] WS Server started")
+	server.hub = newWSHub(server.handleClientMessage)
+	server.emotes.Start()
+
+	log.Println("[CreateServer] WS Server created")
 	return server
 }
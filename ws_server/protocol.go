@@ -0,0 +1,135 @@
+package ws_server
+
+import "overtube/chat_stream"
+
+// Versão do protocolo do /ws, deve subir sempre que um campo mudar de significado ou sair.
+// O esquema publicado em web_server/www/protocol.schema.json precisa acompanhar estes tipos.
+const PROTOCOL_VERSION = 1
+
+type PayloadType string
+
+const (
+	// Servidor -> overlay
+	PayloadTypeWelcome    PayloadType = "welcome"
+	PayloadTypeMessage    PayloadType = "msg"
+	PayloadTypeEvent      PayloadType = "event"
+	PayloadTypeCommand    PayloadType = "cmd"
+	PayloadTypeSubscribed PayloadType = "subscribed"
+	PayloadTypeError      PayloadType = "error"
+
+	// Overlay -> servidor
+	PayloadTypeHello     PayloadType = "hello"
+	PayloadTypeSubscribe PayloadType = "subscribe"
+)
+
+type CommandType string
+
+const (
+	CommandTypeRefresh CommandType = "refresh"
+	CommandTypeClear   CommandType = "clear"
+	CommandTypeDelete  CommandType = "delete"
+)
+
+// Recursos anunciados no welcome, overlays de terceiros devem checar antes de usar
+var protocolCapabilities = []string{
+	"backlog",
+	"subscribe",
+	"filter.platforms",
+	"filter.channels",
+	"filter.eventTypes",
+	"filter.minRole",
+	"filter.excludeBots",
+	"cmd.refresh",
+	"cmd.clear",
+	"cmd.delete",
+}
+
+// HelloPayload é a primeira mensagem do overlay, overlays antigos que não mandam continuam funcionando
+type HelloPayload struct {
+	Type            PayloadType `json:"type"`
+	ProtocolVersion int         `json:"protocolVersion"`
+	Client          string      `json:"client,omitempty"`
+}
+
+type WelcomePayload struct {
+	Type            PayloadType `json:"type"`
+	ProtocolVersion int         `json:"protocolVersion"`
+	Capabilities    []string    `json:"capabilities"`
+	BacklogSize     int         `json:"backlogSize"` // Quantas mensagens o histórico guarda agora, não a capacidade
+}
+
+type SubscribePayload struct {
+	Type PayloadType `json:"type"`
+	clientFilter
+}
+
+// SubscribedPayload confirma o filtro aplicado, já normalizado pelo servidor
+type SubscribedPayload struct {
+	Type PayloadType `json:"type"`
+	clientFilter
+}
+
+type ErrorPayload struct {
+	Type    PayloadType `json:"type"`
+	Message string      `json:"message"`
+}
+
+type MessagePayload struct {
+	Type         PayloadType                         `json:"type"`
+	Id           string                              `json:"id"`
+	UserId       string                              `json:"userId"`
+	UserName     string                              `json:"userName"`
+	Platform     chat_stream.PlatformType            `json:"platform"`
+	Channel      string                              `json:"channel"`
+	ChannelLabel string                              `json:"channelLabel"`
+	Timestamp    int64                               `json:"timestamp"`
+	MessageParts []chat_stream.ChatStreamMessagePart `json:"messageParts"`
+	Badges       []chat_stream.ChatUserBadge         `json:"badges"`
+	Bits         int                                 `json:"bits"`
+}
+
+type EventPayload struct {
+	Type          PayloadType                         `json:"type"`
+	EventType     chat_stream.ChatStreamEventType     `json:"eventType"`
+	UserId        string                              `json:"userId"`
+	UserName      string                              `json:"userName"`
+	Platform      chat_stream.PlatformType            `json:"platform"`
+	Channel       string                              `json:"channel"`
+	ChannelLabel  string                              `json:"channelLabel"`
+	Timestamp     int64                               `json:"timestamp"`
+	SystemText    string                              `json:"systemText"`
+	MessageParts  []chat_stream.ChatStreamMessagePart `json:"messageParts"`
+	Badges        []chat_stream.ChatUserBadge         `json:"badges"`
+	Months        int                                 `json:"months"`
+	Tier          string                              `json:"tier"`
+	Gifter        string                              `json:"gifter"`
+	Recipient     string                              `json:"recipient"`
+	GiftCount     int                                 `json:"giftCount"`
+	ViewerCount   int                                 `json:"viewerCount"`
+	Color         string                              `json:"color"`
+	Amount        string                              `json:"amount"`
+	Currency      string                              `json:"currency"`
+	HeaderColor   string                              `json:"headerColor"`
+	BodyColor     string                              `json:"bodyColor"`
+	StickerImgUrl string                              `json:"stickerImgUrl"`
+}
+
+// CommandPayload é usado pelos comandos sem parâmetros (refresh e clear)
+type CommandPayload struct {
+	Type    PayloadType `json:"type"`
+	Command CommandType `json:"command"`
+}
+
+type DeletePayload struct {
+	Type         PayloadType                        `json:"type"`
+	Command      CommandType                        `json:"command"`
+	Platform     chat_stream.PlatformType           `json:"platform"`
+	Channel      string                             `json:"channel"`
+	DeletionType chat_stream.ChatStreamDeletionType `json:"deletionType"`
+	MessageId    string                             `json:"messageId"`
+	UserId       string                             `json:"userId"`
+}
+
+func newCommandPayload(command CommandType) CommandPayload {
+	return CommandPayload{Type: PayloadTypeCommand, Command: command}
+}
@@ -0,0 +1,61 @@
+package ws_server
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"overtube/chat_stream"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const RECORDINGS_DIR = "recordings"
+
+// chatRecorder grava em JSON Lines tudo o que o servidor envia aos overlays, um arquivo por sessão
+type chatRecorder struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+func newChatRecorder() (*chatRecorder, error) {
+	err := os.MkdirAll(RECORDINGS_DIR, 0755)
+	if err != nil {
+		return nil, err
+	}
+	fileName := "chat_" + time.Now().Format("2006-01-02_15-04-05") + ".jsonl"
+	file, err := os.OpenFile(filepath.Join(RECORDINGS_DIR, fileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("[chatRecorder] Recording chat to", file.Name())
+	return &chatRecorder{file: file}, nil
+}
+
+func (r *chatRecorder) Write(record chat_stream.ChatRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	record.RecordedAt = time.Now().UnixMilli()
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Println("[chatRecorder] Fail to encode record", err)
+		return
+	}
+	_, err = r.file.Write(append(line, '\n'))
+	if err != nil {
+		log.Println("[chatRecorder] Fail to write record", err)
+	}
+}
+
+func (r *chatRecorder) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	r.file.Close()
+	r.file = nil
+}
@@ -0,0 +1,98 @@
+package ws_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const SSE_RETRY_MILLIS = 1000
+
+// ServeSSE envia os mesmos payloads do /ws como Server-Sent Events, para ferramentas que preferem
+// um stream HTTP. Os filtros vêm na query como no link do overlay e o Last-Event-ID retoma a
+// partir do backlog, sem repetir o que o cliente já recebeu.
+func (s *WSChatStreamServer) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	filter := parseFilterQuery(r.URL.Query())
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+
+	s.backlog.mu.Lock()
+	client := s.hub.registerQueue(filter)
+	if client == nil {
+		s.backlog.mu.Unlock()
+		log.Println("[WSChatStreamServer] Denying new SSE connection, max connections reached")
+		http.Error(w, "max connections reached", http.StatusServiceUnavailable)
+		return
+	}
+	resumeFrom, err := strconv.ParseUint(lastEventId, 10, 64)
+	if err != nil || resumeFrom > s.backlog.lastId {
+		// Id inválido ou de uma execução anterior do app, manda o histórico inteiro
+		resumeFrom = 0
+	}
+	for _, entry := range s.backlog.snapshot(&filter) {
+		if entry.id > resumeFrom {
+			client.enqueue(entry.queued())
+		}
+	}
+	backlogSize := s.backlog.length
+	s.backlog.mu.Unlock()
+	defer s.hub.unregister(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	fmt.Fprintf(w, "retry: %d\n\n", SSE_RETRY_MILLIS)
+	welcome, _ := json.Marshal(WelcomePayload{
+		Type:            PayloadTypeWelcome,
+		ProtocolVersion: PROTOCOL_VERSION,
+		Capabilities:    protocolCapabilities,
+		BacklogSize:     backlogSize,
+	})
+	writeSSE(w, queuedPayload{data: welcome})
+	flusher.Flush()
+
+	ticker := time.NewTicker(CLIENT_PING_PERIOD)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client.done:
+			return
+		case payload := <-client.send:
+			err := writeSSE(w, payload)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			// Comentário SSE, mantém a conexão viva e detecta o cliente que sumiu
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, payload queuedPayload) error {
+	if payload.id > 0 {
+		_, err := fmt.Fprintf(w, "id: %d\n", payload.id)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "data: %s\n\n", payload.data)
+	return err
+}
@@ -1,27 +1,25 @@
 package ws_server
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"overtube/chat_stream"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-const MAX_WS_CONNS = 10
-
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 2048,
-	CheckOrigin: func(r *http.Request) bool {
-		origin, ok := r.Header["Origin"]
-		return ok && len(origin) == 1 && origin[0] == "http://localhost:1337"
-	},
-}
+const (
+	MAX_WS_CONNS = 10
+	// O SSE tem limite próprio, assim ferramentas externas não tiram a vaga dos overlays
+	MAX_SSE_CONNS = 10
+)
 
 type ChannelConnectionStatus uint
 
@@ -29,212 +27,542 @@ const (
 	ChannelConnectionStopped ChannelConnectionStatus = iota
 	ChannelConnectionStarting
 	ChannelConnectionRunning
+	ChannelConnectionWaiting // Canal ainda não está ao vivo, aguardando a live começar
 )
 
+func (s ChannelConnectionStatus) String() string {
+	switch s {
+	case ChannelConnectionStarting:
+		return "starting"
+	case ChannelConnectionRunning:
+		return "running"
+	case ChannelConnectionWaiting:
+		return "waiting"
+	default:
+		return "stopped"
+	}
+}
+
 type ChannelConnectionStatusEvent struct {
 	Platform chat_stream.PlatformType
+	Channel  string
 	Status   ChannelConnectionStatus
 }
 
-type WSConnection struct {
-	Conn *websocket.Conn
-	mu   sync.Mutex
-}
-
-func (c *WSConnection) Send(data any) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.Conn.WriteJSON(data)
+// sourceStream associa uma conexão de chat ao rótulo opcional exibido no overlay
+type sourceStream struct {
+	stream chat_stream.ChatStreamCon
+	label  string
+	done   chan struct{}
 }
 
 type WSChatStreamServer struct {
-	Port            uint
-	srcStreams      []chat_stream.ChatStreamCon
-	conns           []*WSConnection
-	srv             *http.Server
+	AllowedOrigins  []string // Origens extras aceitas, "*" aceita qualquer uma
+	upgrader        websocket.Upgrader
+	srcStreams      []*sourceStream
+	streamsMu       sync.Mutex
+	hub             *wsHub
+	backlog         *chatBacklog
+	pumps           sync.WaitGroup
+	recorder        *chatRecorder
+	emotes          *chat_stream.EmoteProvider
+	rewriteImageURL func(string) string
+	publishListener func(PublishedPayload)
+	recorderMu      sync.Mutex
+	stopped         chan struct{}
 	StatusEventChan chan ChannelConnectionStatusEvent
 }
 
-func (s *WSChatStreamServer) Start() bool {
-	s.srv = &http.Server{Addr: "127.0.0.1:" + fmt.Sprintf("%d", s.Port)}
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		if len(s.srcStreams) <= 0 {
-			log.Println("[WSChatStreamServer] Denying new connection, no chat stream live")
-			return
-		}
-		if len(s.conns) >= MAX_WS_CONNS {
-			log.Println("[WSChatStreamServer] Denying new connection, max connections reached")
-			return
-		}
-		conn, err := upgrader.Upgrade(w, r, nil)
-		if err != nil {
-			log.Println("[WSChatStreamServer] Fail to upgrade to WS", err)
-			return
+// ServeWS atende o /ws, é montado pelo servidor da página para que o overlay use a mesma porta.
+// O overlay pode conectar antes de qualquer canal, as mensagens chegam assim que um canal conectar
+func (s *WSChatStreamServer) ServeWS(w http.ResponseWriter, r *http.Request) {
+	if s.hub.full(false) {
+		log.Println("[WSChatStreamServer] Denying new connection, max connections reached")
+		http.Error(w, "max connections reached", http.StatusServiceUnavailable)
+		return
+	}
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("[WSChatStreamServer] Fail to upgrade to WS", err)
+		return
+	}
+	// Filtro inicial até o overlay mandar o "subscribe", evita enviar o histórico filtrado duas vezes
+	if !s.registerClient(conn, parseFilterQuery(r.URL.Query())) {
+		log.Println("[WSChatStreamServer] Denying new connection, max connections reached")
+		conn.Close()
+	}
+}
+
+// checkOrigin aceita a página servida pelo mesmo host e porta usados para chegar aqui, o que cobre
+// tanto o OBS deste PC quanto o de outro PC da rede, e as origens extras configuradas
+func (s *WSChatStreamServer) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range s.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
 		}
-		newClient := &WSConnection{Conn: conn}
-		s.conns = append(s.conns, newClient)
-		go s.sendAllUserIdForClient(newClient)
-	})
+	}
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(originUrl.Host, r.Host)
+}
 
-	s.StatusEventChan = make(chan ChannelConnectionStatusEvent)
+func (s *WSChatStreamServer) Stop() {
+	close(s.stopped)
+	s.streamsMu.Lock()
+	for _, src := range s.srcStreams {
+		close(src.done)
+	}
+	s.srcStreams = []*sourceStream{}
+	s.streamsMu.Unlock()
+	s.pumps.Wait()
 
-	go s.srv.ListenAndServe()
-	go s.loopChatStreamMessages()
-	go s.clearOldConnections()
+	s.hub.closeAll()
+	s.SetRecording(false)
+	s.emotes.Stop()
+}
 
-	return true
+// Done fecha no Stop, quem lê o StatusEventChan deve parar por ele pois o canal nunca é fechado:
+// conexões ainda podem estar avisando o status enquanto o servidor para
+func (s *WSChatStreamServer) Done() <-chan struct{} {
+	return s.stopped
 }
 
-func (s *WSChatStreamServer) Stop() {
-	s.closeAllSockets()
-	if s.srv != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		s.srv.Shutdown(ctx)
+// DisconnectClients derruba os overlays conectados, eles reconectam e passam de novo pela validação do token
+func (s *WSChatStreamServer) DisconnectClients() {
+	s.hub.closeAll()
+}
+
+// registerClient conecta o overlay ao hub já com o histórico recente na fila, o backlog fica travado
+// para que nenhuma mensagem chegue duplicada ou se perca entre o histórico e as novas
+func (s *WSChatStreamServer) registerClient(conn *websocket.Conn, filter clientFilter) bool {
+	s.backlog.mu.Lock()
+	defer s.backlog.mu.Unlock()
+	client := s.hub.register(conn, filter)
+	if client == nil {
+		return false
 	}
-	s.srv = nil
-	close(s.StatusEventChan)
-	s.StatusEventChan = nil
+	for _, entry := range s.backlog.snapshot(&filter) {
+		client.enqueue(entry.queued())
+	}
+	return true
 }
 
-func (s *WSChatStreamServer) closeAllSockets() {
-	for _, conn := range s.conns {
-		conn.Conn.Close()
+func (s *WSChatStreamServer) handleClientMessage(client *wsClient, message []byte) {
+	var envelope struct {
+		Type PayloadType `json:"type"`
+	}
+	err := json.Unmarshal(message, &envelope)
+	if err != nil {
+		log.Println("[WSChatStreamServer] Invalid message from client", err)
+		s.sendToClient(client, ErrorPayload{Type: PayloadTypeError, Message: "invalid message"})
+		return
+	}
+	switch envelope.Type {
+	case PayloadTypeHello:
+		var hello HelloPayload
+		json.Unmarshal(message, &hello)
+		s.welcomeClient(client, hello)
+	case PayloadTypeSubscribe:
+		var subscribe SubscribePayload
+		json.Unmarshal(message, &subscribe)
+		s.subscribeClient(client, subscribe.clientFilter)
+	default:
+		log.Println("[WSChatStreamServer] Unknown message type from client", envelope.Type)
+		s.sendToClient(client, ErrorPayload{Type: PayloadTypeError, Message: "unknown message type: " + string(envelope.Type)})
 	}
-	s.conns = []*WSConnection{}
 }
 
-func (s *WSChatStreamServer) clearOldConnections() {
-	for {
-		if s.srv == nil {
-			return
-		}
-		newList := []*WSConnection{}
-		for _, conn := range s.conns {
-			conn.Send(map[string]any{
-				"type":    "cmd",
-				"command": "ping",
-			})
-			var v map[string]any = nil
-			err := conn.Conn.ReadJSON(&v)
-			if err == nil && v != nil && v["command"] == "pong" {
-				newList = append(newList, conn)
-			} else {
-				log.Println("[WSChatStreamServer] No response from client, disconnected")
-				conn.Conn.Close()
-			}
-		}
-		s.conns = newList
-		time.Sleep(3 * time.Second)
+// welcomeClient responde o hello, um overlay que fala outra versão do protocolo é desconectado
+func (s *WSChatStreamServer) welcomeClient(client *wsClient, hello HelloPayload) {
+	if hello.ProtocolVersion != PROTOCOL_VERSION {
+		log.Println("[WSChatStreamServer] Client uses unsupported protocol version", hello.ProtocolVersion, hello.Client)
+		s.sendToClient(client, ErrorPayload{
+			Type:    PayloadTypeError,
+			Message: fmt.Sprintf("unsupported protocol version %d, server speaks %d", hello.ProtocolVersion, PROTOCOL_VERSION),
+		})
+		client.close()
+		return
 	}
+	s.backlog.mu.Lock()
+	backlogSize := s.backlog.length
+	s.backlog.mu.Unlock()
+	s.sendToClient(client, WelcomePayload{
+		Type:            PayloadTypeWelcome,
+		ProtocolVersion: PROTOCOL_VERSION,
+		Capabilities:    protocolCapabilities,
+		BacklogSize:     backlogSize,
+	})
 }
 
-func (s *WSChatStreamServer) sendNewUserIdForAllClents(stream chat_stream.ChatStreamCon) {
-	if s.srv == nil {
+// subscribeClient troca o filtro do overlay, se mudou o chat é limpo e o histórico reenviado já filtrado
+func (s *WSChatStreamServer) subscribeClient(client *wsClient, filter clientFilter) {
+	filter.normalize()
+	s.backlog.mu.Lock()
+	defer s.backlog.mu.Unlock()
+	s.sendToClient(client, SubscribedPayload{Type: PayloadTypeSubscribed, clientFilter: filter})
+	if client.getFilter().equals(filter) {
 		return
 	}
-	for _, conn := range s.conns {
-		s.sendNewUserId(conn, stream)
+	client.setFilter(filter)
+	s.sendToClient(client, newCommandPayload(CommandTypeClear))
+	for _, entry := range s.backlog.snapshot(&filter) {
+		client.enqueue(entry.queued())
 	}
 }
 
-func (s *WSChatStreamServer) sendAllUserIdForClient(conn *WSConnection) {
-	time.Sleep(1 * time.Second) // Wait to guarantee client is connected
-	if s.srv == nil {
+func (s *WSChatStreamServer) sendToClient(client *wsClient, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Println("[WSChatStreamServer] Fail to encode payload", err)
 		return
 	}
-	for _, client := range s.srcStreams {
-		s.sendNewUserId(conn, client)
-	}
+	client.enqueue(queuedPayload{data: payload})
 }
 
-func (s *WSChatStreamServer) sendNewUserId(conn *WSConnection, stream chat_stream.ChatStreamCon) {
-	conn.Send(map[string]any{
-		"type":     "cmd",
-		"command":  "setNewUserId",
-		"platform": stream.GetPlatform(),
-		"id":       stream.GetUserId(),
+// SetBacklogSize define quantas mensagens recentes são reenviadas para cada overlay que conecta
+func (s *WSChatStreamServer) SetBacklogSize(size int) {
+	s.backlog.mu.Lock()
+	defer s.backlog.mu.Unlock()
+	s.backlog.resize(size)
+}
+
+// PublishedPayload é uma mensagem ou evento já enviado aos overlays, Kind é "msg" ou o tipo do evento
+type PublishedPayload struct {
+	Kind     string
+	Platform chat_stream.PlatformType
+	Channel  string
+	Text     string
+	Data     []byte // Payload com as URLs originais das imagens, o proxy /img só serve para os overlays
+}
+
+// SetPublishListener define quem é avisado de cada mensagem e evento enviados, o listener não deve bloquear
+func (s *WSChatStreamServer) SetPublishListener(listener func(PublishedPayload)) {
+	s.publishListener = listener
+}
+
+// publish guarda o payload no histórico e envia para os overlays conectados, o listener recebe
+// original, o mesmo payload antes das imagens passarem pelo proxy
+func (s *WSChatStreamServer) publish(entry backlogEntry, data any, original any, text string) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Println("[WSChatStreamServer] Fail to encode payload", err)
+		return
+	}
+	entry.payload = payload
+	s.backlog.mu.Lock()
+	entry.id = s.backlog.nextId()
+	s.backlog.push(entry)
+	s.hub.broadcastPayload(entry.queued(), entry.meta)
+	s.backlog.mu.Unlock()
+
+	if s.publishListener == nil {
+		return
+	}
+	originalPayload, err := json.Marshal(original)
+	if err != nil {
+		log.Println("[WSChatStreamServer] Fail to encode payload", err)
+		return
+	}
+	s.publishListener(PublishedPayload{
+		Kind:     entry.meta.kind,
+		Platform: entry.meta.platform,
+		Channel:  entry.meta.channel,
+		Text:     text,
+		Data:     originalPayload,
 	})
 }
 
-func (s *WSChatStreamServer) loopChatStreamMessages() {
-	log.Println("[WSChatStreamServer] loopChatStreamMessages started")
-	for {
-		if s.srv == nil {
-			log.Println("[WSChatStreamServer] No server live, stop handling messages")
-			return
-		}
-		if len(s.srcStreams) <= 0 && len(s.conns) > 0 {
-			log.Println("[WSChatStreamServer] No chat stream live, closing sockets")
-			s.closeAllSockets()
-		}
-		s.handleChatStreamMessages()
-		time.Sleep(50 * time.Millisecond)
+// sendStatus não bloqueia o encerramento do servidor caso ninguém esteja mais lendo os status
+func (s *WSChatStreamServer) sendStatus(event ChannelConnectionStatusEvent) {
+	select {
+	case s.StatusEventChan <- event:
+	case <-s.stopped:
 	}
 }
 
-func (s *WSChatStreamServer) handleChatStreamMessages() {
-	for i, chatStream := range s.srcStreams {
-		if !chatStream.IsConnected() {
-			s.RemoveStream(i)
-			break
-		}
+// pumpStream repassa as mensagens de uma conexão de chat para o hub assim que chegam
+func (s *WSChatStreamServer) pumpStream(src *sourceStream) {
+	defer s.pumps.Done()
+	chatStream := src.stream
+	// Os canais são capturados aqui pois o Close da conexão os troca por nil
+	messages := chatStream.GetMessagesChan()
+	events := chatStream.GetEventsChan()
+	deletions := chatStream.GetDeletionsChan()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
 		select {
-		case msg := <-chatStream.GetMessagesChan():
-			data := map[string]any{
-				"type":         "msg",
-				"userName":     msg.Name,
-				"platform":     msg.Platform,
-				"timestamp":    msg.Timestamp,
-				"messageParts": msg.MessageParts,
-				"badges":       msg.Badges,
+		case <-src.done:
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				s.removeSourceStream(src)
+				return
+			}
+			s.handleMessage(src, msg)
+		case event, ok := <-events:
+			if !ok {
+				s.removeSourceStream(src)
+				return
 			}
-			for _, ws := range s.conns {
-				ws.Send(data)
+			s.handleEvent(src, event)
+		case deletion, ok := <-deletions:
+			if !ok {
+				s.removeSourceStream(src)
+				return
+			}
+			s.handleDeletion(src, deletion)
+		case <-ticker.C:
+			if !chatStream.IsConnected() {
+				s.removeSourceStream(src)
+				return
 			}
-		default:
-			// Do nothing
 		}
 	}
 }
 
-func (s *WSChatStreamServer) RemoveAllStreamsFromPlatform(platform chat_stream.PlatformType) {
-	newList := []chat_stream.ChatStreamCon{}
-	for _, stream := range s.srcStreams {
-		if stream.GetPlatform() != platform {
-			newList = append(newList, stream)
-		} else {
-			s.StatusEventChan <- ChannelConnectionStatusEvent{
-				Platform: platform,
-				Status:   ChannelConnectionStopped,
-			}
+func (s *WSChatStreamServer) handleMessage(src *sourceStream, msg chat_stream.ChatStreamMessage) {
+	chatStream := src.stream
+	msg.MessageParts = s.emotes.ResolveMessageParts(msg.Platform, chatStream.GetUserId(), msg.MessageParts)
+	data := MessagePayload{
+		Type:         PayloadTypeMessage,
+		Id:           msg.Id,
+		UserId:       msg.UserId,
+		UserName:     msg.Name,
+		Platform:     msg.Platform,
+		Channel:      chatStream.GetChannelId(),
+		ChannelLabel: src.label,
+		Timestamp:    msg.Timestamp,
+		MessageParts: msg.MessageParts,
+		Badges:       msg.Badges,
+		Bits:         msg.Bits,
+	}
+	original := data
+	data.MessageParts = s.rewriteMessagePartsImages(data.MessageParts)
+	data.Badges = s.rewriteBadgesImages(data.Badges)
+	s.record(src, chat_stream.ChatRecord{
+		Type:    chat_stream.ChatRecordTypeMessage,
+		Message: &msg,
+	})
+	s.publish(backlogEntry{
+		meta: payloadMeta{
+			platform: msg.Platform,
+			channel:  chatStream.GetChannelId(),
+			kind:     FILTER_TYPE_MESSAGE,
+			role:     chat_stream.GetRoleFromBadges(msg.Badges),
+			isBot:    chat_stream.IsKnownChatBot(msg.Name),
+		},
+		messageId: msg.Id,
+		userId:    msg.UserId,
+	}, data, original, msg.GetMessagePlainText())
+}
+
+func (s *WSChatStreamServer) handleEvent(src *sourceStream, event chat_stream.ChatStreamEvent) {
+	chatStream := src.stream
+	event.MessageParts = s.emotes.ResolveMessageParts(event.Platform, chatStream.GetUserId(), event.MessageParts)
+	data := EventPayload{
+		Type:          PayloadTypeEvent,
+		EventType:     event.EventType,
+		UserId:        event.UserId,
+		UserName:      event.Name,
+		Platform:      event.Platform,
+		Channel:       chatStream.GetChannelId(),
+		ChannelLabel:  src.label,
+		Timestamp:     event.Timestamp,
+		SystemText:    event.SystemText,
+		MessageParts:  event.MessageParts,
+		Badges:        event.Badges,
+		Months:        event.Months,
+		Tier:          event.Tier,
+		Gifter:        event.Gifter,
+		Recipient:     event.Recipient,
+		GiftCount:     event.GiftCount,
+		ViewerCount:   event.ViewerCount,
+		Color:         event.Color,
+		Amount:        event.Amount,
+		Currency:      event.Currency,
+		HeaderColor:   event.HeaderColor,
+		BodyColor:     event.BodyColor,
+		StickerImgUrl: event.StickerImgUrl,
+	}
+	original := data
+	data.MessageParts = s.rewriteMessagePartsImages(data.MessageParts)
+	data.Badges = s.rewriteBadgesImages(data.Badges)
+	data.StickerImgUrl = s.rewriteImage(data.StickerImgUrl)
+	s.record(src, chat_stream.ChatRecord{
+		Type:  chat_stream.ChatRecordTypeEvent,
+		Event: &event,
+	})
+	s.publish(backlogEntry{
+		meta: payloadMeta{
+			platform: event.Platform,
+			channel:  chatStream.GetChannelId(),
+			kind:     string(event.EventType),
+		},
+		userId: event.UserId,
+	}, data, original, event.SystemText)
+}
+
+func (s *WSChatStreamServer) handleDeletion(src *sourceStream, deletion chat_stream.ChatStreamDeletion) {
+	chatStream := src.stream
+	data := DeletePayload{
+		Type:         PayloadTypeCommand,
+		Command:      CommandTypeDelete,
+		Platform:     deletion.Platform,
+		Channel:      chatStream.GetChannelId(),
+		DeletionType: deletion.DeletionType,
+		MessageId:    deletion.MessageId,
+		UserId:       deletion.UserId,
+	}
+	s.record(src, chat_stream.ChatRecord{
+		Type:     chat_stream.ChatRecordTypeDeletion,
+		Deletion: &deletion,
+	})
+	s.backlog.mu.Lock()
+	defer s.backlog.mu.Unlock()
+	s.backlog.delete(deletion, chatStream.GetChannelId())
+	s.hub.broadcast(data)
+}
+
+func (s *WSChatStreamServer) RemoveStream(platform chat_stream.PlatformType, channel string) {
+	s.streamsMu.Lock()
+	removed := []*sourceStream{}
+	for _, src := range s.srcStreams {
+		if src.stream.GetPlatform() == platform && src.stream.GetChannelId() == channel {
+			removed = append(removed, src)
 		}
 	}
-	s.srcStreams = newList
+	s.streamsMu.Unlock()
+	for _, src := range removed {
+		s.removeSourceStream(src)
+	}
 }
 
-func (s *WSChatStreamServer) AddStream(stream chat_stream.ChatStreamCon) {
-	s.srcStreams = append(s.srcStreams, stream)
-	s.StatusEventChan <- ChannelConnectionStatusEvent{
+func (s *WSChatStreamServer) AddStream(stream chat_stream.ChatStreamCon, label string) {
+	src := &sourceStream{stream: stream, label: label, done: make(chan struct{})}
+	s.streamsMu.Lock()
+	s.srcStreams = append(s.srcStreams, src)
+	s.pumps.Add(1)
+	s.streamsMu.Unlock()
+	go s.pumpStream(src)
+
+	s.sendStatus(ChannelConnectionStatusEvent{
 		Platform: stream.GetPlatform(),
+		Channel:  stream.GetChannelId(),
 		Status:   ChannelConnectionRunning,
+	})
+	s.emotes.AddChannel(stream.GetPlatform(), stream.GetUserId())
+}
+
+// removeSourceStream pode ser chamado tanto pelo orquestrador quanto pela própria goroutine da
+// conexão, só quem de fato a retirar da lista avisa o status
+func (s *WSChatStreamServer) removeSourceStream(src *sourceStream) {
+	s.streamsMu.Lock()
+	index := slices.Index(s.srcStreams, src)
+	if index < 0 {
+		s.streamsMu.Unlock()
+		return
 	}
-	s.sendNewUserIdForAllClents(stream)
+	s.srcStreams = slices.Delete(s.srcStreams, index, index+1)
+	close(src.done)
+	releaseEmotes := !s.hasUserStreamLocked(src.stream)
+	s.streamsMu.Unlock()
+
+	if releaseEmotes {
+		s.emotes.RemoveChannel(src.stream.GetPlatform(), src.stream.GetUserId())
+	}
+	s.sendStatus(ChannelConnectionStatusEvent{
+		Platform: src.stream.GetPlatform(),
+		Channel:  src.stream.GetChannelId(),
+		Status:   ChannelConnectionStopped,
+	})
 }
 
-func (s *WSChatStreamServer) RefreshClients() {
-	for _, conn := range s.conns {
-		conn.Send(map[string]any{
-			"type":    "cmd",
-			"command": "refresh",
-		})
+// hasUserStreamLocked diz se outra conexão ainda usa os emotes do mesmo usuário, deve ser chamado com streamsMu travado
+func (s *WSChatStreamServer) hasUserStreamLocked(stream chat_stream.ChatStreamCon) bool {
+	for _, src := range s.srcStreams {
+		if src.stream != stream && src.stream.GetPlatform() == stream.GetPlatform() && src.stream.GetUserId() == stream.GetUserId() {
+			return true
+		}
 	}
+	return false
 }
 
-func (s *WSChatStreamServer) RemoveStream(i int) {
-	s.StatusEventChan <- ChannelConnectionStatusEvent{
-		Platform: s.srcStreams[i].GetPlatform(),
-		Status:   ChannelConnectionStopped,
+// SetRecording liga ou desliga a gravação do chat, cada vez que é ligada um novo arquivo é criado
+func (s *WSChatStreamServer) SetRecording(enabled bool) {
+	s.recorderMu.Lock()
+	defer s.recorderMu.Unlock()
+	if !enabled {
+		if s.recorder != nil {
+			s.recorder.Close()
+			s.recorder = nil
+		}
+		return
+	}
+	if s.recorder != nil {
+		return
+	}
+	recorder, err := newChatRecorder()
+	if err != nil {
+		log.Println("[WSChatStreamServer] Fail to start chat recording", err)
+		return
+	}
+	s.recorder = recorder
+}
+
+// record grava o que chegou de src, o replay não é gravado de novo para não duplicar a gravação
+func (s *WSChatStreamServer) record(src *sourceStream, record chat_stream.ChatRecord) {
+	if src.stream.GetPlatform() == chat_stream.PlatformTypeReplay {
+		return
 	}
-	s.srcStreams = append(s.srcStreams[:i], s.srcStreams[i+1:]...)
+	record.Channel = src.stream.GetChannelId()
+	record.ChannelLabel = src.label
+	s.recorderMu.Lock()
+	recorder := s.recorder
+	s.recorderMu.Unlock()
+	if recorder != nil {
+		recorder.Write(record)
+	}
+}
+
+// SetImageURLRewriter define como as URLs de emotes, badges e stickers são reescritas antes de
+// irem para os overlays, a gravação continua com as URLs originais
+func (s *WSChatStreamServer) SetImageURLRewriter(rewriter func(string) string) {
+	s.rewriteImageURL = rewriter
+}
+
+func (s *WSChatStreamServer) rewriteImage(imgUrl string) string {
+	if s.rewriteImageURL == nil || imgUrl == "" {
+		return imgUrl
+	}
+	return s.rewriteImageURL(imgUrl)
+}
+
+func (s *WSChatStreamServer) rewriteMessagePartsImages(parts []chat_stream.ChatStreamMessagePart) []chat_stream.ChatStreamMessagePart {
+	rewritten := make([]chat_stream.ChatStreamMessagePart, len(parts))
+	for i, part := range parts {
+		part.EmoteImgUrl = s.rewriteImage(part.EmoteImgUrl)
+		rewritten[i] = part
+	}
+	return rewritten
+}
+
+func (s *WSChatStreamServer) rewriteBadgesImages(badges []chat_stream.ChatUserBadge) []chat_stream.ChatUserBadge {
+	rewritten := make([]chat_stream.ChatUserBadge, len(badges))
+	for i, badge := range badges {
+		badge.ImgSrc = s.rewriteImage(badge.ImgSrc)
+		rewritten[i] = badge
+	}
+	return rewritten
+}
+
+func (s *WSChatStreamServer) RefreshClients() {
+	s.hub.broadcast(newCommandPayload(CommandTypeRefresh))
 }